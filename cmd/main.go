@@ -10,9 +10,13 @@ import (
 	"time"
 
 	"github.com/aglili/waakye-directory/internal/config"
+	"github.com/aglili/waakye-directory/internal/janitor"
 	"github.com/aglili/waakye-directory/internal/logger"
+	"github.com/aglili/waakye-directory/internal/moderation"
 	"github.com/aglili/waakye-directory/internal/provider"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
 	"github.com/aglili/waakye-directory/internal/routes"
+	"github.com/aglili/waakye-directory/internal/storage"
 	"github.com/rs/zerolog/log"
 )
 
@@ -25,6 +29,10 @@ import (
 // @BasePath /api/v1
 // @schemes http
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
 func main() {
 	// Initialize logger
 	logger.Init(config.GetEnvOrDefault("ENV", "development"))
@@ -34,6 +42,16 @@ func main() {
 	cfg := config.LoadConfig()
 	log.Info().Interface("config", cfg).Msg("Loaded configuration")
 
+	if cfg.JWTSecret == "" {
+		log.Fatal().Msg("JWT_SECRET must be set")
+	}
+	if err := cfg.ValidateRanking(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid ranking configuration")
+	}
+	if err := cfg.ValidateUploadJanitor(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid upload janitor configuration")
+	}
+
 	// Initialize database
 	db, err := config.InitializeDB(cfg)
 	if err != nil {
@@ -41,8 +59,26 @@ func main() {
 	}
 	defer db.Close()
 
+	// Load the terms that flag reviews for moderators
+	wordlist, err := moderation.LoadWordlist(cfg.ModerationWordlistFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load the moderation wordlist")
+	}
+
+	// Pick where uploaded files are kept
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up upload storage")
+	}
+
 	// Create a new provider
-	prov := provider.NewProvider(db, cfg)
+	prov := provider.NewProvider(db, cfg, wordlist, store)
+
+	// Delete uploads nothing ended up using, until the server shuts down
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	uploadJanitor := janitor.NewUploadJanitor(postgres.NewUploadRepository(db), store, cfg.UploadOrphanGrace, cfg.UploadJanitorInterval)
+	go uploadJanitor.Run(janitorCtx)
 
 	// Setup routes
 	router := routes.SetupRoutes(prov)
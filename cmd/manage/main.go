@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/auth"
+	"github.com/aglili/waakye-directory/internal/config"
+	"github.com/aglili/waakye-directory/internal/hours"
+	"github.com/aglili/waakye-directory/internal/logger"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `Usage: manage <command> [flags]
+
+Commands:
+  seed-admin           Create the first admin account, or promote an existing user to admin
+  backfill-hours       Parse free text operating hours into structured hours where none exist yet
+  repair-rating-stats  Recompute every vendor's rating stats and every review's helpful count
+`
+
+func main() {
+	logger.Init(config.GetEnvOrDefault("ENV", "development"))
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+
+	db, err := config.InitializeDB(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize the database")
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "seed-admin":
+		err = seedAdmin(ctx, postgres.NewUserRepository(db), args)
+	case "backfill-hours":
+		err = backfillHours(ctx, postgres.NewHoursRepository(db), args)
+	case "repair-rating-stats":
+		err = repairRatingStats(ctx, postgres.NewRatingRepository(db))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal().Err(err).Str("command", command).Msg("Command failed")
+	}
+}
+
+// seedAdmin creates an admin account. The password is read from ADMIN_PASSWORD when the flag is
+// omitted so it does not end up in shell history.
+func seedAdmin(ctx context.Context, users postgres.UserRepository, args []string) error {
+	flags := flag.NewFlagSet("seed-admin", flag.ExitOnError)
+	email := flags.String("email", config.GetEnvOrDefault("ADMIN_EMAIL", ""), "admin email (or ADMIN_EMAIL)")
+	password := flags.String("password", config.GetEnvOrDefault("ADMIN_PASSWORD", ""), "admin password (or ADMIN_PASSWORD)")
+	displayName := flags.String("name", config.GetEnvOrDefault("ADMIN_NAME", "Admin"), "admin display name (or ADMIN_NAME)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	normalizedEmail := strings.ToLower(strings.TrimSpace(*email))
+	if normalizedEmail == "" {
+		return errors.New("an admin email is required")
+	}
+
+	existing, err := users.GetUserByEmail(ctx, normalizedEmail)
+	if err != nil && !errors.Is(err, postgres.ErrUserNotFound) {
+		return err
+	}
+
+	if existing != nil {
+		if err := users.UpdateUserRole(ctx, existing.ID, models.RoleAdmin); err != nil {
+			return err
+		}
+		log.Info().Str("email", normalizedEmail).Msg("Promoted existing user to admin")
+		return nil
+	}
+
+	if len(*password) < 8 {
+		return errors.New("an admin password of at least 8 characters is required")
+	}
+
+	passwordHash, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	admin := models.User{
+		Email:        normalizedEmail,
+		PasswordHash: passwordHash,
+		DisplayName:  *displayName,
+		Role:         models.RoleAdmin,
+	}
+	if err := users.CreateUser(ctx, &admin); err != nil {
+		return err
+	}
+
+	log.Info().Str("email", normalizedEmail).Str("user_id", admin.ID.String()).Msg("Created admin user")
+	return nil
+}
+
+// backfillHours makes a best-effort pass over vendors that only have free text hours. Text the
+// parser cannot read is reported and left for the owner or a moderator to enter by hand.
+func backfillHours(ctx context.Context, repository postgres.HoursRepository, args []string) error {
+	flags := flag.NewFlagSet("backfill-hours", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "print what would be stored without writing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	vendors, err := repository.ListVendorsWithoutHours(ctx)
+	if err != nil {
+		return err
+	}
+
+	parsed, skipped := 0, 0
+	for vendorID, text := range vendors {
+		spans, err := hours.Parse(text)
+		if err != nil {
+			skipped++
+			log.Warn().Str("vendor_id", vendorID.String()).Str("operating_hours", text).Msg("Could not parse operating hours")
+			continue
+		}
+
+		if *dryRun {
+			log.Info().Str("vendor_id", vendorID.String()).Str("operating_hours", text).Int("spans", len(spans)).Msg("Would store hours")
+		} else if err := repository.ReplaceWeeklyHours(ctx, vendorID, spans); err != nil {
+			return err
+		}
+		parsed++
+	}
+
+	log.Info().Int("parsed", parsed).Int("skipped", skipped).Bool("dry_run", *dryRun).Msg("Backfilled operating hours")
+	return nil
+}
+
+// repairRatingStats rebuilds vendor_rating_stats from scratch and recounts helpful votes, for when
+// the stats are suspected to have drifted from the ratings, e.g. after ratings were edited or users
+// deleted by hand
+func repairRatingStats(ctx context.Context, repository postgres.RatingsRepository) error {
+	vendors, err := repository.RepairRatingStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	reviews, err := repository.RepairHelpfulCounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int64("vendors", vendors).Int64("reviews_recounted", reviews).Msg("Repaired rating stats")
+	return nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken is returned when a token is malformed, has a bad signature or has expired
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+const (
+	tokenIssuer     = "waakye-directory"
+	accessTokenType = "access"
+)
+
+// Claims are the JWT claims carried by an access token
+type Claims struct {
+	Subject   string      `json:"sub"`
+	Role      models.Role `json:"role"`
+	Issuer    string      `json:"iss"`
+	Type      string      `json:"typ"`
+	IssuedAt  int64       `json:"iat"`
+	ExpiresAt int64       `json:"exp"`
+}
+
+// UserID returns the subject of the token as a UUID
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+// TokenManager issues and verifies HS256 signed access tokens
+type TokenManager struct {
+	secret    []byte
+	accessTTL time.Duration
+	now       func() time.Time
+}
+
+func NewTokenManager(secret string, accessTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		secret:    []byte(secret),
+		accessTTL: accessTTL,
+		now:       time.Now,
+	}
+}
+
+// IssueAccessToken creates a short lived access token for a user
+func (m *TokenManager) IssueAccessToken(userID uuid.UUID, role models.Role) (string, time.Time, error) {
+	issuedAt := m.now()
+	expiresAt := issuedAt.Add(m.accessTTL)
+
+	claims := Claims{
+		Subject:   userID.String(),
+		Role:      role,
+		Issuer:    tokenIssuer,
+		Type:      accessTokenType,
+		IssuedAt:  issuedAt.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+
+	token, err := m.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseAccessToken verifies the signature and expiry of an access token and returns its claims
+func (m *TokenManager) ParseAccessToken(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Algorithm != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, m.signature(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != tokenIssuer || claims.Type != accessTokenType {
+		return nil, ErrInvalidToken
+	}
+	if m.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+func (m *TokenManager) sign(claims Claims) (string, error) {
+	header, err := json.Marshal(tokenHeader{Algorithm: "HS256", Type: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token header: %w", err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(m.signature(signingInput)), nil
+}
+
+func (m *TokenManager) signature(signingInput string) []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,26 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// dummyHash is compared against when a login email is unknown so both paths take the same time
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("waakye-directory-dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword hashes a plain text password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the stored bcrypt hash.
+// Pass an empty hash for unknown users to keep the timing consistent.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
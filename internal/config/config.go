@@ -1,22 +1,51 @@
 package config
 
 import (
+	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/aglili/waakye-directory/internal/models"
 	"github.com/joho/godotenv"
+	"github.com/rs/zerolog/log"
 )
 
 type Config struct {
-	Env            string
-	DBHost         string
-	DBPort         string
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	RedisHost      string
-	RedisPort      string
-	RedisPassword  string
-	FileUploadPath string
+	Env             string
+	DBHost          string
+	DBPort          string
+	DBUser          string
+	DBPassword      string
+	DBName          string
+	RedisHost       string
+	RedisPort       string
+	RedisPassword   string
+	FileUploadPath  string
+	JWTSecret       string `json:"-"`
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Top rated ranking defaults, used when a request does not pick its own
+	RankingMethod      string
+	RankingMinReviews  int
+	RankingPriorWeight float64
+	// File of extra terms that flag a review for moderators, on top of the built-in list
+	ModerationWordlistFile string
+	// Where uploads are kept ("local" or "s3") and the base URL clients download them from
+	StorageBackend  string
+	UploadPublicURL string
+	// S3-compatible bucket used when StorageBackend is "s3"
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string `json:"-"`
+	S3ForcePathStyle bool
+	// How long an upload may go unused by any vendor, review or verification evidence before it is
+	// deleted, and how often that is checked
+	UploadOrphanGrace     time.Duration
+	UploadJanitorInterval time.Duration
 }
 
 func LoadConfig() *Config {
@@ -24,17 +53,67 @@ func LoadConfig() *Config {
 	_ = godotenv.Load()
 
 	return &Config{
-		Env:            GetEnvOrDefault("APP_ENV", "development"),
-		DBHost:         GetEnvOrDefault("DB_HOST", "localhost"),
-		DBPort:         GetEnvOrDefault("DB_PORT", "5432"),
-		DBUser:         GetEnvOrDefault("DB_USER", "postgres"),
-		DBPassword:     GetEnvOrDefault("DB_PASSWORD", ""),
-		DBName:         GetEnvOrDefault("DB_NAME", "postgres"),
-		RedisHost:      GetEnvOrDefault("REDIS_HOST", "localhost"),
-		RedisPort:      GetEnvOrDefault("REDIS_PORT", "6379"),
-		RedisPassword:  GetEnvOrDefault("REDIS_PASSWORD", ""),
-		FileUploadPath: GetEnvOrDefault("FILE_UPLOAD_PATH", "uploads"),
+		Env:             GetEnvOrDefault("APP_ENV", "development"),
+		DBHost:          GetEnvOrDefault("DB_HOST", "localhost"),
+		DBPort:          GetEnvOrDefault("DB_PORT", "5432"),
+		DBUser:          GetEnvOrDefault("DB_USER", "postgres"),
+		DBPassword:      GetEnvOrDefault("DB_PASSWORD", ""),
+		DBName:          GetEnvOrDefault("DB_NAME", "postgres"),
+		RedisHost:       GetEnvOrDefault("REDIS_HOST", "localhost"),
+		RedisPort:       GetEnvOrDefault("REDIS_PORT", "6379"),
+		RedisPassword:   GetEnvOrDefault("REDIS_PASSWORD", ""),
+		FileUploadPath:  GetEnvOrDefault("FILE_UPLOAD_PATH", "uploads"),
+		JWTSecret:       GetEnvOrDefault("JWT_SECRET", ""),
+		AccessTokenTTL:  GetDurationOrDefault("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: GetDurationOrDefault("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		RankingMethod:      GetEnvOrDefault("RANKING_METHOD", "bayesian"),
+		RankingMinReviews:  GetIntOrDefault("RANKING_MIN_REVIEWS", 3),
+		RankingPriorWeight: GetFloatOrDefault("RANKING_PRIOR_WEIGHT", 10),
+
+		ModerationWordlistFile: GetEnvOrDefault("MODERATION_WORDLIST_FILE", ""),
+
+		StorageBackend:   GetEnvOrDefault("STORAGE_BACKEND", "local"),
+		UploadPublicURL:  GetEnvOrDefault("UPLOAD_PUBLIC_URL", "http://localhost:8080/uploads"),
+		S3Endpoint:       GetEnvOrDefault("S3_ENDPOINT", ""),
+		S3Region:         GetEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:         GetEnvOrDefault("S3_BUCKET", ""),
+		S3AccessKey:      GetEnvOrDefault("S3_ACCESS_KEY", ""),
+		S3SecretKey:      GetEnvOrDefault("S3_SECRET_KEY", ""),
+		S3ForcePathStyle: GetBoolOrDefault("S3_FORCE_PATH_STYLE", true),
+
+		UploadOrphanGrace:     GetDurationOrDefault("UPLOAD_ORPHAN_GRACE", 24*time.Hour),
+		UploadJanitorInterval: GetDurationOrDefault("UPLOAD_JANITOR_INTERVAL", time.Hour),
+	}
+}
+
+// ValidateRanking checks the top rated ranking defaults, so a bad RANKING_* value stops the API at
+// startup instead of failing every request that relies on it
+func (c *Config) ValidateRanking() error {
+	if !models.RankingMethod(c.RankingMethod).Valid() {
+		return fmt.Errorf("RANKING_METHOD must be bayesian or wilson, got %q", c.RankingMethod)
+	}
+	if c.RankingMinReviews < 1 {
+		return fmt.Errorf("RANKING_MIN_REVIEWS must be at least 1, got %d", c.RankingMinReviews)
+	}
+	if math.IsNaN(c.RankingPriorWeight) || math.IsInf(c.RankingPriorWeight, 0) || c.RankingPriorWeight < 0 {
+		return fmt.Errorf("RANKING_PRIOR_WEIGHT must be a number of at least 0, got %v", c.RankingPriorWeight)
+	}
+
+	return nil
+}
+
+// ValidateUploadJanitor checks the orphaned upload clean-up settings. A zero interval would crash
+// the janitor, and a zero grace would delete uploads before they can be linked to anything.
+func (c *Config) ValidateUploadJanitor() error {
+	if c.UploadOrphanGrace <= 0 {
+		return fmt.Errorf("UPLOAD_ORPHAN_GRACE must be greater than 0, got %s", c.UploadOrphanGrace)
 	}
+	if c.UploadJanitorInterval <= 0 {
+		return fmt.Errorf("UPLOAD_JANITOR_INTERVAL must be greater than 0, got %s", c.UploadJanitorInterval)
+	}
+
+	return nil
 }
 
 func GetEnvOrDefault(key, defaultValue string) string {
@@ -43,3 +122,67 @@ func GetEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// GetDurationOrDefault reads a Go duration string (e.g. "15m") from the environment
+func GetDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Invalid duration, using default")
+		return defaultValue
+	}
+
+	return duration
+}
+
+// GetIntOrDefault reads an integer from the environment
+func GetIntOrDefault(key string, defaultValue int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Invalid integer, using default")
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// GetBoolOrDefault reads a boolean (true, false, 1, 0) from the environment
+func GetBoolOrDefault(key string, defaultValue bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Invalid boolean, using default")
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// GetFloatOrDefault reads a decimal number from the environment
+func GetFloatOrDefault(key string, defaultValue float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Invalid number, using default")
+		return defaultValue
+	}
+
+	return parsed
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/auth"
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	userRepository postgres.UserRepository
+	tokens         *auth.TokenManager
+	refreshTTL     time.Duration
+}
+
+func NewAuthHandler(userRepository postgres.UserRepository, tokens *auth.TokenManager, refreshTTL time.Duration) *AuthHandler {
+	return &AuthHandler{
+		userRepository: userRepository,
+		tokens:         tokens,
+		refreshTTL:     refreshTTL,
+	}
+}
+
+// Signup godoc
+// @Summary Create an account
+// @Description Register a new user and return an access and refresh token pair
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body models.SignupRequest true "Signup details"
+// @Success 201 {object} CreatedResponse "Account created successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request or email already registered"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/auth/signup [post]
+func (h *AuthHandler) Signup(ctx *gin.Context) {
+	var request models.SignupRequest
+	if !utils.BindJSON(ctx, &request, "Failed to create account") {
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		userMessage := "Failed to create account"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	user := models.User{
+		Email:        strings.ToLower(strings.TrimSpace(request.Email)),
+		PasswordHash: passwordHash,
+		DisplayName:  strings.TrimSpace(request.DisplayName),
+	}
+
+	if err := h.userRepository.CreateUser(ctx, &user); err != nil {
+		if errors.Is(err, postgres.ErrEmailTaken) {
+			userMessage := "An account with this email already exists"
+			utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to create account"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	tokens, err := h.issueTokens(ctx, &user)
+	if err != nil {
+		userMessage := "Failed to create account"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	createdMessage := "Account created successfully"
+	utils.RespondWithCreated(ctx, createdMessage, tokens)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange an email and password for an access and refresh token pair
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Login credentials"
+// @Success 200 {object} CreatedResponse "Logged in successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Invalid email or password"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/auth/login [post]
+func (h *AuthHandler) Login(ctx *gin.Context) {
+	var request models.LoginRequest
+	if !utils.BindJSON(ctx, &request, "Failed to log in") {
+		return
+	}
+
+	user, err := h.userRepository.GetUserByEmail(ctx, strings.TrimSpace(request.Email))
+	if err != nil && !errors.Is(err, postgres.ErrUserNotFound) {
+		userMessage := "Failed to log in"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	passwordHash := ""
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+
+	if !auth.CheckPassword(passwordHash, request.Password) {
+		userMessage := "Invalid email or password"
+		utils.RespondWithUnauthorized(ctx, "invalid credentials", userMessage)
+		return
+	}
+
+	tokens, err := h.issueTokens(ctx, user)
+	if err != nil {
+		userMessage := "Failed to log in"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	loginMessage := "Logged in successfully"
+	utils.RespondWithOK(ctx, loginMessage, tokens)
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token pair. Refresh tokens can only be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} CreatedResponse "Tokens refreshed successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Invalid or expired refresh token"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(ctx *gin.Context) {
+	var request models.RefreshTokenRequest
+	if !utils.BindJSON(ctx, &request, "Failed to refresh tokens") {
+		return
+	}
+
+	userID, err := h.userRepository.ConsumeRefreshToken(ctx, auth.HashRefreshToken(request.RefreshToken))
+	if err != nil {
+		if errors.Is(err, postgres.ErrRefreshTokenInvalid) {
+			userMessage := "Session expired, please log in again"
+			utils.RespondWithUnauthorized(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to refresh tokens"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	user, err := h.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			userMessage := "Session expired, please log in again"
+			utils.RespondWithUnauthorized(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to refresh tokens"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	tokens, err := h.issueTokens(ctx, user)
+	if err != nil {
+		userMessage := "Failed to refresh tokens"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	refreshMessage := "Tokens refreshed successfully"
+	utils.RespondWithOK(ctx, refreshMessage, tokens)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} CreatedResponse "Logged out successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/auth/logout [post]
+func (h *AuthHandler) Logout(ctx *gin.Context) {
+	var request models.RefreshTokenRequest
+	if !utils.BindJSON(ctx, &request, "Failed to log out") {
+		return
+	}
+
+	if err := h.userRepository.RevokeRefreshToken(ctx, auth.HashRefreshToken(request.RefreshToken)); err != nil {
+		userMessage := "Failed to log out"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	logoutMessage := "Logged out successfully"
+	utils.RespondWithOK(ctx, logoutMessage, nil)
+}
+
+// Me godoc
+// @Summary Current user
+// @Description Get the account of the authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} CreatedResponse "User retrieved successfully"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/auth/me [get]
+func (h *AuthHandler) Me(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	user, err := h.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			utils.RespondWithUnauthorized(ctx, err.Error(), "Authentication required")
+			return
+		}
+		userMessage := "Failed to get user"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	getMessage := "User retrieved successfully"
+	utils.RespondWithOK(ctx, getMessage, user)
+}
+
+func (h *AuthHandler) issueTokens(ctx *gin.Context, user *models.User) (*models.AuthTokens, error) {
+	accessToken, accessExpiresAt, err := h.tokens.IssueAccessToken(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := time.Now().Add(h.refreshTTL)
+	if err := h.userRepository.StoreRefreshToken(ctx, user.ID, refreshHash, refreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+		TokenType:             "Bearer",
+		User:                  user,
+	}, nil
+}
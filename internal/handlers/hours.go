@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/hours"
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetVendorHours godoc
+// @Summary Get vendor opening hours
+// @Description Get a vendor's weekly hours and current or upcoming overrides, in Africa/Accra time
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param id path string true "Vendor ID"
+// @Success 200 {object} CreatedResponse "Vendor hours retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/hours [get]
+func (h *VendorHandler) GetVendorHours(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	vendorHours, err := h.hoursRepository.GetVendorHours(ctx, parsedUUID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to get vendor hours"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	getMessage := "Vendor hours retrieved successfully"
+	utils.RespondWithOK(ctx, getMessage, vendorHours)
+}
+
+// SetVendorHours godoc
+// @Summary Set vendor opening hours
+// @Description Replace a vendor's weekly hours. Weekday 0 is Sunday; times are HH:MM in Africa/Accra.
+// @Description A closing time that is not after the opening time runs past midnight.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param hours body models.SetVendorHoursRequest true "Weekly hours"
+// @Success 200 {object} CreatedResponse "Vendor hours updated successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this vendor"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/hours [put]
+func (h *VendorHandler) SetVendorHours(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.SetVendorHoursRequest
+	if !utils.BindJSON(ctx, &request, "Failed to update vendor hours") {
+		return
+	}
+
+	spans := make([]hours.Span, 0, len(request.Weekly))
+	for i, weekly := range request.Weekly {
+		opens, err := hours.ParseClock(weekly.OpensAt)
+		if err != nil {
+			userMessage := "Failed to update vendor hours"
+			utils.RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, []utils.FieldError{clockFieldError(fmt.Sprintf("weekly[%d].opens_at", i))})
+			return
+		}
+		closes, err := hours.ParseClock(weekly.ClosesAt)
+		if err != nil {
+			userMessage := "Failed to update vendor hours"
+			utils.RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, []utils.FieldError{clockFieldError(fmt.Sprintf("weekly[%d].closes_at", i))})
+			return
+		}
+		spans = append(spans, hours.Span{Weekday: time.Weekday(weekly.Weekday), Opens: opens, Closes: closes})
+	}
+
+	if !h.authorizeVendorChange(ctx, parsedUUID, middleware.PermVendorEditOwn, middleware.PermVendorEditAny) {
+		return
+	}
+
+	if err := h.hoursRepository.ReplaceWeeklyHours(ctx, parsedUUID, spans); err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to update vendor hours"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	h.respondWithVendorHours(ctx, parsedUUID, "Vendor hours updated successfully")
+}
+
+// AddHoursOverride godoc
+// @Summary Add a vendor hours override
+// @Description Close a vendor, or change its hours, for every day from starts_on to ends_on (YYYY-MM-DD, inclusive)
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param override body models.CreateHoursOverrideRequest true "Override"
+// @Success 201 {object} CreatedResponse "Vendor hours override added successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this vendor"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/hours/overrides [post]
+func (h *VendorHandler) AddHoursOverride(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.CreateHoursOverrideRequest
+	if !utils.BindJSON(ctx, &request, "Failed to add vendor hours override") {
+		return
+	}
+
+	override, fieldError := toHoursOverride(&request)
+	if fieldError != nil {
+		userMessage := "Failed to add vendor hours override"
+		devMessage := fmt.Sprintf("%s %s", fieldError.Field, fieldError.Message)
+		utils.RespondWithUnprocessableEntity(ctx, devMessage, userMessage, []utils.FieldError{*fieldError})
+		return
+	}
+
+	if !h.authorizeVendorChange(ctx, parsedUUID, middleware.PermVendorEditOwn, middleware.PermVendorEditAny) {
+		return
+	}
+
+	created, err := h.hoursRepository.AddOverride(ctx, parsedUUID, userID, override, request.Note)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to add vendor hours override"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	createdMessage := "Vendor hours override added successfully"
+	utils.RespondWithCreated(ctx, createdMessage, created)
+}
+
+// DeleteHoursOverride godoc
+// @Summary Remove a vendor hours override
+// @Description Remove a holiday, closure or special hours from a vendor
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param override_id path string true "Override ID"
+// @Success 200 {object} CreatedResponse "Vendor hours override removed successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this vendor"
+// @Failure 404 {object} NotFoundResponse "Override not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/hours/overrides/{override_id} [delete]
+func (h *VendorHandler) DeleteHoursOverride(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	overrideID, ok := utils.ParseUUID(ctx, "override_id")
+	if !ok {
+		return
+	}
+
+	if !h.authorizeVendorChange(ctx, parsedUUID, middleware.PermVendorEditOwn, middleware.PermVendorEditAny) {
+		return
+	}
+
+	if err := h.hoursRepository.DeleteOverride(ctx, parsedUUID, overrideID); err != nil {
+		if errors.Is(err, postgres.ErrHoursOverrideNotFound) {
+			userMessage := "Hours override does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to remove vendor hours override"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	h.respondWithVendorHours(ctx, parsedUUID, "Vendor hours override removed successfully")
+}
+
+// respondWithVendorHours sends the vendor's schedule as it stands after a change
+func (h *VendorHandler) respondWithVendorHours(ctx *gin.Context, vendorID uuid.UUID, message string) {
+	vendorHours, err := h.hoursRepository.GetVendorHours(ctx, vendorID)
+	if err != nil {
+		userMessage := "Failed to get vendor hours"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	utils.RespondWithOK(ctx, message, vendorHours)
+}
+
+// toHoursOverride validates an override request and converts it to Accra dates and clocks
+func toHoursOverride(request *models.CreateHoursOverrideRequest) (hours.Override, *utils.FieldError) {
+	var override hours.Override
+	var err error
+
+	if override.StartsOn, err = time.ParseInLocation(hours.DateLayout, request.StartsOn, hours.Accra); err != nil {
+		return override, &utils.FieldError{Field: "starts_on", Rule: "date", Message: "must be a date in YYYY-MM-DD form"}
+	}
+	if override.EndsOn, err = time.ParseInLocation(hours.DateLayout, request.EndsOn, hours.Accra); err != nil {
+		return override, &utils.FieldError{Field: "ends_on", Rule: "date", Message: "must be a date in YYYY-MM-DD form"}
+	}
+	if override.EndsOn.Before(override.StartsOn) {
+		return override, &utils.FieldError{Field: "ends_on", Rule: "gtefield", Message: "must not be before starts_on"}
+	}
+
+	override.Closed = request.IsClosed
+	if override.Closed {
+		return override, nil
+	}
+
+	if request.OpensAt == nil {
+		return override, &utils.FieldError{Field: "opens_at", Rule: "required_unless", Message: "is required unless is_closed is set"}
+	}
+	if request.ClosesAt == nil {
+		return override, &utils.FieldError{Field: "closes_at", Rule: "required_unless", Message: "is required unless is_closed is set"}
+	}
+	if override.Opens, err = hours.ParseClock(*request.OpensAt); err != nil {
+		fieldError := clockFieldError("opens_at")
+		return override, &fieldError
+	}
+	if override.Closes, err = hours.ParseClock(*request.ClosesAt); err != nil {
+		fieldError := clockFieldError("closes_at")
+		return override, &fieldError
+	}
+
+	return override, nil
+}
+
+// clockFieldError reports a time of day that is not HH:MM
+func clockFieldError(field string) utils.FieldError {
+	return utils.FieldError{Field: field, Rule: "time", Message: "must be a time of day in HH:MM form"}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on what the map endpoint draws
+const (
+	// maxMapPoints is the most individual markers returned for one viewport
+	maxMapPoints = 500
+	// clusterMaxZoom is the zoom level from which vendors are never clustered
+	clusterMaxZoom = 15
+	// gridCellsPerTile splits each 256px map tile into this many cluster cells per side
+	gridCellsPerTile = 4
+)
+
+// GetVendorMap godoc
+// @Summary Get vendors for a map viewport
+// @Description Get every vendor inside a bounding box as an RFC 7946 GeoJSON FeatureCollection.
+// @Description When zoom is below 15 and the viewport holds more than 500 vendors, nearby vendors are grouped into
+// @Description clusters with cluster=true, a point_count and a point at their centroid. Otherwise at most 500 vendors,
+// @Description the best rated, are returned and truncated is set when some were left out.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param bbox query string true "Viewport as minLng,minLat,maxLng,maxLat"
+// @Param zoom query int false "Map zoom level (0-22), enables clustering when low"
+// @Param is_verified query bool false "Only verified (true) or unverified (false) vendors"
+// @Param open_now query bool false "Only vendors open (true) or closed (false) right now"
+// @Success 200 {object} models.FeatureCollection "GeoJSON FeatureCollection"
+// @Failure 400 {object} BadRequestResponse "Invalid bounding box or zoom"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/map [get]
+func (h *VendorHandler) GetVendorMap(ctx *gin.Context) {
+	bounds, err := parseBoundingBox(ctx.Query("bbox"))
+	if err != nil {
+		userMessage := "Failed to get vendor map"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	zoom, err := utils.ParseOptionalInt(ctx, "zoom", 0, 22)
+	if err != nil {
+		userMessage := "Failed to get vendor map"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	var filter models.VendorFilter
+	if filter.IsVerified, err = utils.ParseOptionalBool(ctx, "is_verified"); err != nil {
+		userMessage := "Failed to get vendor map"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+	if filter.OpenNow, err = utils.ParseOptionalBool(ctx, "open_now"); err != nil {
+		userMessage := "Failed to get vendor map"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountVendorsInBounds(ctx, *bounds, filter)
+	if err != nil {
+		userMessage := "Failed to get vendor map"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	clustered := zoom != nil && *zoom < clusterMaxZoom && totalItems > maxMapPoints
+
+	var points []models.MapPoint
+	if clustered {
+		points, err = h.repository.ClusterVendorsInBounds(ctx, *bounds, filter, clusterCellDegrees(*zoom))
+	} else {
+		points, err = h.repository.GetVendorsInBounds(ctx, *bounds, filter, maxMapPoints)
+	}
+	if err != nil {
+		userMessage := "Failed to get vendor map"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	collection := models.FeatureCollection{
+		Type:       "FeatureCollection",
+		BBox:       []float64{bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat},
+		Features:   make([]models.Feature, 0, len(points)),
+		TotalItems: totalItems,
+		Clustered:  clustered,
+		Truncated:  !clustered && totalItems > maxMapPoints,
+	}
+	for _, point := range points {
+		collection.Features = append(collection.Features, models.NewPointFeature(point))
+	}
+
+	// Map libraries load GeoJSON directly, so it is not wrapped in the usual envelope
+	ctx.Header("Content-Type", "application/geo+json")
+	ctx.JSON(http.StatusOK, collection)
+}
+
+// parseBoundingBox reads a minLng,minLat,maxLng,maxLat viewport
+func parseBoundingBox(value string) (*models.BoundingBox, error) {
+	if value == "" {
+		return nil, errors.New("'bbox' is required as minLng,minLat,maxLng,maxLat")
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("'bbox' must have four values: minLng,minLat,maxLng,maxLat")
+	}
+
+	var coords [4]float64
+	for i, part := range parts {
+		coord, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'bbox' value %q: must be a number", part)
+		}
+		coords[i] = coord
+	}
+
+	bounds := &models.BoundingBox{MinLng: coords[0], MinLat: coords[1], MaxLng: coords[2], MaxLat: coords[3]}
+	if bounds.MinLng < -180 || bounds.MaxLng > 180 || bounds.MinLat < -90 || bounds.MaxLat > 90 {
+		return nil, errors.New("'bbox' longitudes must be within -180 to 180 and latitudes within -90 to 90")
+	}
+	if bounds.MinLng >= bounds.MaxLng || bounds.MinLat >= bounds.MaxLat {
+		return nil, errors.New("'bbox' minimums must be less than its maximums")
+	}
+
+	return bounds, nil
+}
+
+// clusterCellDegrees is the side of a cluster cell at a zoom level, so clusters stay roughly the
+// same size on screen as the map zooms
+func clusterCellDegrees(zoom int) float64 {
+	return 360 / (math.Pow(2, float64(zoom)) * gridCellsPerTile)
+}
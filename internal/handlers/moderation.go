@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ModerationHandler struct {
+	repository postgres.ModerationRepository
+	storage    storage.Storage
+}
+
+func NewModerationHandler(repository postgres.ModerationRepository, store storage.Storage) *ModerationHandler {
+	return &ModerationHandler{
+		repository: repository,
+		storage:    store,
+	}
+}
+
+// ReportReview godoc
+// @Summary Report a review
+// @Description Report an abusive, spam or otherwise unfair review. The review is put in the moderation queue and stays visible until a moderator decides on it.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Param report body models.ReportReviewRequest true "Report"
+// @Success 201 {object} CreatedResponse "Review reported successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format or already reported"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews/{rating_id}/reports [post]
+func (h *ModerationHandler) ReportReview(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	vendorID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ratingID, ok := utils.ParseUUID(ctx, "rating_id")
+	if !ok {
+		return
+	}
+
+	var request models.ReportReviewRequest
+	if !utils.BindJSON(ctx, &request, "Failed to report review") {
+		return
+	}
+
+	if err := h.repository.ReportReview(ctx, vendorID, ratingID, userID, &request); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrRatingNotFound):
+			utils.RespondWithNotFound(ctx, err.Error(), "Review does not exist")
+		case errors.Is(err, postgres.ErrAlreadyReported):
+			utils.RespondWithBadRequest(ctx, err.Error(), "You have already reported this review")
+		default:
+			utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to report review")
+		}
+		return
+	}
+
+	createdMessage := "Review reported successfully"
+	utils.RespondWithCreated(ctx, createdMessage, nil)
+}
+
+// ListReviewQueue godoc
+// @Summary List the review moderation queue
+// @Description List flagged or hidden reviews with their open reports, the ones waiting longest first (admins and moderators only)
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Review status (flagged, hidden)" default(flagged)
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} PaginatedResponse "Review queue retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/reviews [get]
+func (h *ModerationHandler) ListReviewQueue(ctx *gin.Context) {
+	params, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		userMessage := "Failed to list review queue"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	status := models.ReviewStatus(ctx.DefaultQuery("status", string(models.ReviewFlagged)))
+	if status != models.ReviewFlagged && status != models.ReviewHidden {
+		userMessage := "Invalid review status"
+		utils.RespondWithBadRequest(ctx, fmt.Sprintf("unknown status %q", status), userMessage)
+		return
+	}
+
+	queue, err := h.repository.ListModerationQueue(ctx, status, params.Page, params.PageSize)
+	if err != nil {
+		userMessage := "Failed to list review queue"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountModerationQueue(ctx, status)
+	if err != nil {
+		userMessage := "Failed to list review queue"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	for i := range queue {
+		queue[i].Review.ResolveURLs(h.storage.URL)
+	}
+
+	getMessage := "Review queue retrieved successfully"
+	utils.SendPaginatedResponse(ctx, queue, params.Page, params.PageSize, totalItems, getMessage)
+}
+
+// GetReviewModeration godoc
+// @Summary Get a review's moderation record
+// @Description Get a review with its moderation state, reports and log (admins and moderators only)
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Rating ID"
+// @Success 200 {object} CreatedResponse "Review moderation retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/reviews/{id} [get]
+func (h *ModerationHandler) GetReviewModeration(ctx *gin.Context) {
+	ratingID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	moderation, err := h.repository.GetReviewModeration(ctx, ratingID)
+	if err != nil {
+		respondWithModerationError(ctx, err, "Failed to get review moderation")
+		return
+	}
+
+	moderation.Review.ResolveURLs(h.storage.URL)
+
+	getMessage := "Review moderation retrieved successfully"
+	utils.RespondWithOK(ctx, getMessage, moderation)
+}
+
+// HideReview godoc
+// @Summary Hide a review
+// @Description Hide a review from listings and leave it out of the vendor's ratings, resolving its open reports (admins and moderators only)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Rating ID"
+// @Param decision body models.ModerationDecisionRequest false "Moderator notes"
+// @Success 200 {object} CreatedResponse "Review hidden successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request or invalid state change"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/reviews/{id}/hide [post]
+func (h *ModerationHandler) HideReview(ctx *gin.Context) {
+	h.decide(ctx, models.ReviewHidden, "Review hidden successfully")
+}
+
+// RestoreReview godoc
+// @Summary Restore a review
+// @Description Publish a flagged or hidden review again, resolving its open reports (admins and moderators only)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Rating ID"
+// @Param decision body models.ModerationDecisionRequest false "Moderator notes"
+// @Success 200 {object} CreatedResponse "Review restored successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request or invalid state change"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/reviews/{id}/restore [post]
+func (h *ModerationHandler) RestoreReview(ctx *gin.Context) {
+	h.decide(ctx, models.ReviewPublished, "Review restored successfully")
+}
+
+// DeleteReview godoc
+// @Summary Delete a review
+// @Description Delete a review for good, resolving its open reports (admins and moderators only)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Rating ID"
+// @Param decision body models.ModerationDecisionRequest false "Moderator notes"
+// @Success 200 {object} CreatedResponse "Review deleted successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/reviews/{id} [delete]
+func (h *ModerationHandler) DeleteReview(ctx *gin.Context) {
+	actorID, ratingID, request, ok := bindModerationDecision(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.DeleteReview(ctx, ratingID, actorID, request.Notes); err != nil {
+		respondWithModerationError(ctx, err, "Failed to delete review")
+		return
+	}
+
+	deletedMessage := "Review deleted successfully"
+	utils.RespondWithOK(ctx, deletedMessage, nil)
+}
+
+func (h *ModerationHandler) decide(ctx *gin.Context, to models.ReviewStatus, successMessage string) {
+	actorID, ratingID, request, ok := bindModerationDecision(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.TransitionReview(ctx, ratingID, actorID, to, request.Notes); err != nil {
+		if errors.Is(err, postgres.ErrInvalidReviewTransition) {
+			utils.RespondWithBadRequest(ctx, err.Error(), fmt.Sprintf("Review cannot be moved to %s from its current state", to))
+			return
+		}
+		respondWithModerationError(ctx, err, "Failed to moderate review")
+		return
+	}
+
+	moderation, err := h.repository.GetReviewModeration(ctx, ratingID)
+	if err != nil {
+		respondWithModerationError(ctx, err, "Failed to moderate review")
+		return
+	}
+
+	moderation.Review.ResolveURLs(h.storage.URL)
+
+	utils.RespondWithOK(ctx, successMessage, moderation)
+}
+
+// bindModerationDecision reads the moderator, the review and the optional notes of a decision. It
+// writes the error response when any of them is missing or invalid.
+func bindModerationDecision(ctx *gin.Context) (actorID, ratingID uuid.UUID, request models.ModerationDecisionRequest, ok bool) {
+	actorID, ok = middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	ratingID, ok = utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if ctx.Request.ContentLength != 0 {
+		ok = utils.BindJSON(ctx, &request, "Failed to moderate review")
+	}
+	return
+}
+
+func respondWithModerationError(ctx *gin.Context, err error, userMessage string) {
+	if errors.Is(err, postgres.ErrRatingNotFound) {
+		utils.RespondWithNotFound(ctx, err.Error(), "Review does not exist")
+		return
+	}
+	utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+}
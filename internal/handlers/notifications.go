@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	repository postgres.NotificationRepository
+}
+
+func NewNotificationHandler(repository postgres.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		repository: repository,
+	}
+}
+
+// ListNotifications godoc
+// @Summary List my notifications
+// @Description List the signed in user's notifications, newest first
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} PaginatedResponse "Notifications retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/notifications [get]
+func (h *NotificationHandler) ListNotifications(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	params, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		userMessage := "Failed to list notifications"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	unread, err := utils.ParseOptionalBool(ctx, "unread")
+	if err != nil {
+		userMessage := "Failed to list notifications"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+	unreadOnly := unread != nil && *unread
+
+	notifications, err := h.repository.ListNotifications(ctx, userID, unreadOnly, params.Page, params.PageSize)
+	if err != nil {
+		userMessage := "Failed to list notifications"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountNotifications(ctx, userID, unreadOnly)
+	if err != nil {
+		userMessage := "Failed to list notifications"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	getMessage := "Notifications retrieved successfully"
+	utils.SendPaginatedResponse(ctx, notifications, params.Page, params.PageSize, totalItems, getMessage)
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} CreatedResponse "Notification marked as read"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 404 {object} NotFoundResponse "Notification not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	notificationID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.MarkNotificationRead(ctx, userID, notificationID); err != nil {
+		if errors.Is(err, postgres.ErrNotificationNotFound) {
+			userMessage := "Notification does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to mark notification as read"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	readMessage := "Notification marked as read"
+	utils.RespondWithOK(ctx, readMessage, nil)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all notifications as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} CreatedResponse "Notifications marked as read"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/notifications/read [post]
+func (h *NotificationHandler) MarkAllNotificationsRead(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	marked, err := h.repository.MarkAllNotificationsRead(ctx, userID)
+	if err != nil {
+		userMessage := "Failed to mark notifications as read"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	readMessage := "Notifications marked as read"
+	utils.RespondWithOK(ctx, readMessage, gin.H{"marked": marked})
+}
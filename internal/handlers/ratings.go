@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// UpdateRating godoc
+// @Summary Edit a rating
+// @Description Edit a rating. Only its author or a moderator can do this, and the previous values are kept in the rating's history.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Param rating body models.RateVendorRequest true "Rating object"
+// @Success 200 {object} CreatedResponse "Rating updated successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this rating"
+// @Failure 404 {object} NotFoundResponse "Rating not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/ratings/{rating_id} [put]
+func (h *VendorHandler) UpdateRating(ctx *gin.Context) {
+	vendorID, ratingID, userID, ok := h.authorizeRatingChange(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.RateVendorRequest
+	if !utils.BindJSON(ctx, &request, "Failed to update rating") {
+		return
+	}
+
+	rating, err := h.ratingsRepository.UpdateRating(ctx, vendorID, ratingID, userID, &request)
+	if err != nil {
+		respondWithRatingError(ctx, err, "Failed to update rating")
+		return
+	}
+	h.flagOffensiveReview(ctx, rating)
+	rating.ResolveURLs(h.storage.URL)
+
+	updatedMessage := "Rating updated successfully"
+	utils.RespondWithOK(ctx, updatedMessage, rating)
+}
+
+// DeleteRating godoc
+// @Summary Delete a rating
+// @Description Delete a rating. Only its author or a moderator can do this. The author can then rate the vendor again.
+// @Tags vendors
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Success 200 {object} CreatedResponse "Rating deleted successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this rating"
+// @Failure 404 {object} NotFoundResponse "Rating not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/ratings/{rating_id} [delete]
+func (h *VendorHandler) DeleteRating(ctx *gin.Context) {
+	vendorID, ratingID, userID, ok := h.authorizeRatingChange(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.ratingsRepository.DeleteRating(ctx, vendorID, ratingID, userID); err != nil {
+		respondWithRatingError(ctx, err, "Failed to delete rating")
+		return
+	}
+
+	deletedMessage := "Rating deleted successfully"
+	utils.RespondWithOK(ctx, deletedMessage, nil)
+}
+
+// ListRatingRevisions godoc
+// @Summary List rating edit history
+// @Description List the earlier versions of a rating, newest first, with who edited or deleted it
+// @Tags vendors
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Success 200 {object} CreatedResponse "Rating history retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Insufficient permissions"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/ratings/{rating_id}/revisions [get]
+func (h *VendorHandler) ListRatingRevisions(ctx *gin.Context) {
+	vendorID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ratingID, ok := utils.ParseUUID(ctx, "rating_id")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.ratingsRepository.ListRatingRevisions(ctx, vendorID, ratingID)
+	if err != nil {
+		userMessage := "Failed to get rating history"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	getMessage := "Rating history retrieved successfully"
+	utils.RespondWithOK(ctx, getMessage, revisions)
+}
+
+// MarkReviewHelpful godoc
+// @Summary Mark a review as helpful
+// @Description Mark a review as helpful, once per user. Marking it again changes nothing.
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Success 200 {object} CreatedResponse "Review marked as helpful"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format or own review"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews/{rating_id}/helpful [post]
+func (h *VendorHandler) MarkReviewHelpful(ctx *gin.Context) {
+	h.setHelpfulVote(ctx, true, "Review marked as helpful")
+}
+
+// UnmarkReviewHelpful godoc
+// @Summary Take back a helpful vote
+// @Description Undo marking a review as helpful
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Success 200 {object} CreatedResponse "Helpful vote removed"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews/{rating_id}/helpful [delete]
+func (h *VendorHandler) UnmarkReviewHelpful(ctx *gin.Context) {
+	h.setHelpfulVote(ctx, false, "Helpful vote removed")
+}
+
+func (h *VendorHandler) setHelpfulVote(ctx *gin.Context, helpful bool, successMessage string) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	vendorID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ratingID, ok := utils.ParseUUID(ctx, "rating_id")
+	if !ok {
+		return
+	}
+
+	vote, err := h.ratingsRepository.SetHelpfulVote(ctx, vendorID, ratingID, userID, helpful)
+	if err != nil {
+		if errors.Is(err, postgres.ErrOwnReview) {
+			userMessage := "You cannot mark your own review as helpful"
+			utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+			return
+		}
+		respondWithRatingError(ctx, err, "Failed to update helpful vote")
+		return
+	}
+
+	utils.RespondWithOK(ctx, successMessage, vote)
+}
+
+// authorizeRatingChange lets moderators change any rating and everyone else only the ratings they
+// wrote. It writes the error response when refused.
+func (h *VendorHandler) authorizeRatingChange(ctx *gin.Context) (vendorID, ratingID, userID uuid.UUID, ok bool) {
+	userID, ok = middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	vendorID, ok = utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ratingID, ok = utils.ParseUUID(ctx, "rating_id")
+	if !ok {
+		return
+	}
+
+	rating, err := h.ratingsRepository.GetRating(ctx, vendorID, ratingID)
+	if err != nil {
+		respondWithRatingError(ctx, err, "Failed to check rating ownership")
+		return vendorID, ratingID, userID, false
+	}
+
+	if middleware.HasPermission(ctx, middleware.PermRatingModerate) {
+		return vendorID, ratingID, userID, true
+	}
+
+	if rating.Author == nil || rating.Author.ID != userID {
+		userMessage := "You can only manage your own ratings"
+		utils.RespondWithForbidden(ctx, "user did not write this rating", userMessage)
+		return vendorID, ratingID, userID, false
+	}
+
+	return vendorID, ratingID, userID, true
+}
+
+// flagOffensiveReview puts a review whose comment matches the wordlist in the moderation queue.
+// The rating is already saved, so a failure is only logged.
+func (h *VendorHandler) flagOffensiveReview(ctx *gin.Context, review *models.Review) {
+	matches := h.wordlist.Match(review.Comment)
+	if len(matches) == 0 {
+		return
+	}
+
+	notes := "wordlist: " + strings.Join(matches, ", ")
+	if err := h.moderationRepository.FlagReview(ctx, review.ID, notes); err != nil {
+		log.Error().Err(err).Str("rating_id", review.ID.String()).Msg("Failed to flag review")
+	}
+}
+
+func respondWithRatingError(ctx *gin.Context, err error, userMessage string) {
+	switch {
+	case errors.Is(err, postgres.ErrVendorNotFound):
+		utils.RespondWithNotFound(ctx, err.Error(), "Vendor does not exist")
+	case errors.Is(err, postgres.ErrRatingNotFound):
+		utils.RespondWithNotFound(ctx, err.Error(), "Rating does not exist")
+	case errors.Is(err, postgres.ErrInvalidReviewPhotos):
+		utils.RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, []utils.FieldError{{
+			Field:   "photo_ids",
+			Rule:    "upload",
+			Message: "must be images you uploaded through /api/v1/uploads",
+		}})
+	default:
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReviewReplyHandler struct {
+	repository       postgres.ReviewReplyRepository
+	vendorRepository postgres.VendorRepository
+	storage          storage.Storage
+}
+
+func NewReviewReplyHandler(repository postgres.ReviewReplyRepository, vendorRepository postgres.VendorRepository, store storage.Storage) *ReviewReplyHandler {
+	return &ReviewReplyHandler{
+		repository:       repository,
+		vendorRepository: vendorRepository,
+		storage:          store,
+	}
+}
+
+// CreateReply godoc
+// @Summary Reply to a review
+// @Description Post the vendor's public reply to a review. Only the owner of a verified vendor can reply, once per review, and the reviewer is notified.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Param reply body models.ReviewReplyRequest true "Reply"
+// @Success 201 {object} CreatedResponse "Reply posted successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format or already replied"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not the owner of a verified vendor"
+// @Failure 404 {object} NotFoundResponse "Review not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews/{rating_id}/reply [post]
+func (h *ReviewReplyHandler) CreateReply(ctx *gin.Context) {
+	vendorID, ratingID, userID, ok := h.authorizeReply(ctx, false)
+	if !ok {
+		return
+	}
+
+	var request models.ReviewReplyRequest
+	if !utils.BindJSON(ctx, &request, "Failed to post reply") {
+		return
+	}
+
+	review, err := h.repository.CreateReply(ctx, vendorID, ratingID, userID, request.Body)
+	if err != nil {
+		respondWithReplyError(ctx, err, "Failed to post reply")
+		return
+	}
+
+	review.ResolveURLs(h.storage.URL)
+
+	createdMessage := "Reply posted successfully"
+	utils.RespondWithCreated(ctx, createdMessage, review)
+}
+
+// UpdateReply godoc
+// @Summary Edit a reply to a review
+// @Description Edit the vendor's reply to a review. Only the owner of a verified vendor can do this.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Param reply body models.ReviewReplyRequest true "Reply"
+// @Success 200 {object} CreatedResponse "Reply updated successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not the owner of a verified vendor"
+// @Failure 404 {object} NotFoundResponse "Reply not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews/{rating_id}/reply [put]
+func (h *ReviewReplyHandler) UpdateReply(ctx *gin.Context) {
+	vendorID, ratingID, _, ok := h.authorizeReply(ctx, false)
+	if !ok {
+		return
+	}
+
+	var request models.ReviewReplyRequest
+	if !utils.BindJSON(ctx, &request, "Failed to update reply") {
+		return
+	}
+
+	review, err := h.repository.UpdateReply(ctx, vendorID, ratingID, request.Body)
+	if err != nil {
+		respondWithReplyError(ctx, err, "Failed to update reply")
+		return
+	}
+
+	review.ResolveURLs(h.storage.URL)
+
+	updatedMessage := "Reply updated successfully"
+	utils.RespondWithOK(ctx, updatedMessage, review)
+}
+
+// DeleteReply godoc
+// @Summary Delete a reply to a review
+// @Description Delete the vendor's reply to a review. The owner of a verified vendor or a moderator can do this, and the owner can then reply again.
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param rating_id path string true "Rating ID"
+// @Success 200 {object} CreatedResponse "Reply deleted successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not the owner of a verified vendor"
+// @Failure 404 {object} NotFoundResponse "Reply not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews/{rating_id}/reply [delete]
+func (h *ReviewReplyHandler) DeleteReply(ctx *gin.Context) {
+	vendorID, ratingID, _, ok := h.authorizeReply(ctx, true)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.DeleteReply(ctx, vendorID, ratingID); err != nil {
+		respondWithReplyError(ctx, err, "Failed to delete reply")
+		return
+	}
+
+	deletedMessage := "Reply deleted successfully"
+	utils.RespondWithOK(ctx, deletedMessage, nil)
+}
+
+// authorizeReply only lets the owner of a verified vendor manage replies to its reviews, and
+// moderators too when allowModerators is set. It writes the error response when refused.
+func (h *ReviewReplyHandler) authorizeReply(ctx *gin.Context, allowModerators bool) (vendorID, ratingID, userID uuid.UUID, ok bool) {
+	userID, ok = middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	vendorID, ok = utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ratingID, ok = utils.ParseUUID(ctx, "rating_id")
+	if !ok {
+		return
+	}
+
+	if allowModerators && middleware.HasPermission(ctx, middleware.PermRatingModerate) {
+		return vendorID, ratingID, userID, true
+	}
+
+	vendor, err := h.vendorRepository.GetVendorByID(ctx, vendorID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return vendorID, ratingID, userID, false
+		}
+		userMessage := "Failed to check vendor ownership"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return vendorID, ratingID, userID, false
+	}
+
+	if !middleware.HasPermission(ctx, middleware.PermReviewReply) || vendor.OwnerID == nil || *vendor.OwnerID != userID {
+		userMessage := "Only the vendor's owner can reply to its reviews"
+		utils.RespondWithForbidden(ctx, "user does not own this vendor", userMessage)
+		return vendorID, ratingID, userID, false
+	}
+
+	if vendor.VerificationStatus != models.VerificationVerified {
+		userMessage := "The vendor must be verified before replying to reviews"
+		utils.RespondWithForbidden(ctx, "vendor is not verified", userMessage)
+		return vendorID, ratingID, userID, false
+	}
+
+	return vendorID, ratingID, userID, true
+}
+
+func respondWithReplyError(ctx *gin.Context, err error, userMessage string) {
+	switch {
+	case errors.Is(err, postgres.ErrRatingNotFound):
+		utils.RespondWithNotFound(ctx, err.Error(), "Review does not exist")
+	case errors.Is(err, postgres.ErrReplyNotFound):
+		utils.RespondWithNotFound(ctx, err.Error(), "Reply does not exist")
+	case errors.Is(err, postgres.ErrReplyExists):
+		utils.RespondWithBadRequest(ctx, err.Error(), "This review already has a reply, edit it instead")
+	default:
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+	}
+}
@@ -1,77 +1,114 @@
 package handlers
 
-
-
-
+import (
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+)
 
 type UploadResponse struct {
-	FileURL  string `json:"file_url"`
-	FileName string `json:"file_name"`
-	FileSize string `json:"file_size"`
-	FileType string `json:"file_type"`
+	ID        string            `json:"id"`
+	FileURL   string            `json:"file_url"`
+	FileName  string            `json:"file_name"`
+	FileSize  string            `json:"file_size"`
+	FileType  string            `json:"file_type"`
+	Checksum  string            `json:"checksum"`
+	Width     *int              `json:"width"`
+	Height    *int              `json:"height"`
+	Variants  map[string]string `json:"variants"`
+	Srcset    string            `json:"srcset"`
+	Duplicate bool              `json:"duplicate"`
 }
 
-
-
 type BadRequestResponse struct {
-	Error  string `json:"error"`
+	Error   string `json:"error"`
 	Details string `json:"details"`
 }
 
-
 type InternalServerErrorResponse struct {
-	Error  string `json:"error"`
+	Error   string `json:"error"`
 	Details string `json:"details"`
 }
 
-
-type CreatedResponse struct{
-	Data    map[string]interface{}   `json:"data"`
-	Message string      `json:"message"`
-
+type CreatedResponse struct {
+	Data    map[string]interface{} `json:"data"`
+	Message string                 `json:"message"`
 }
 
 type LocationSchema struct {
-	StreetAddress string  `json:"street_address" binding:"required"`
-	City          string  `json:"city" binding:"required"`
-	Region        string  `json:"region" binding:"required"`
-	Latitude      float64 `json:"latitude" binding:"required"`
-	Longitude     float64 `json:"longitude" binding:"required"`
-	Landmark      string  `json:"landmark" binding:"required"`
+	StreetAddress string   `json:"street_address" binding:"required,max=500"`
+	City          string   `json:"city" binding:"required,max=100"`
+	Region        string   `json:"region" binding:"required,max=50"`
+	Latitude      *float64 `json:"latitude" binding:"required,gte=-90,lte=90"`
+	Longitude     *float64 `json:"longitude" binding:"required,gte=-180,lte=180"`
+	Landmark      string   `json:"landmark" binding:"required,max=500"`
 }
 
 type CreateWaakyeVendorSchema struct {
-	Name           string        `json:"name" binding:"required"`
+	Name           string         `json:"name" binding:"required,max=255"`
 	Location       LocationSchema `json:"location" binding:"required"`
-	Description    string        `json:"description" binding:"required"`
-	OperatingHours string        `json:"operating_hours" binding:"required"`
-	ImageURL       string        `json:"image_url" binding:"required"`
-	PhoneNumber    string        `json:"phone_number" binding:"required"`
+	Description    string         `json:"description" binding:"required,max=2000"`
+	OperatingHours string         `json:"operating_hours" binding:"required,max=255"`
+	ImageID        string         `json:"image_id" binding:"required,uuid"`
+	PhoneNumber    string         `json:"phone_number" binding:"required,max=20"`
 }
 
-
-
-type PaginatedResponse struct{
+type PaginatedResponse struct {
 	Data       []map[string]interface{} `json:"data"`
-	Page       int                     `json:"page"`
-	PageSize   int                     `json:"page_size"`
-	TotalItems int64                  `json:"total_items"`
-	TotalPages int                     `json:"total_pages"`
-	Message    string                  `json:"message"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"page_size"`
+	TotalItems int64                    `json:"total_items"`
+	TotalPages int                      `json:"total_pages"`
+	Message    string                   `json:"message"`
 }
 
-
 type NotFoundResponse struct {
 	Error   string `json:"error"`
 	Details string `json:"details"`
 }
 
+// toUpdateRequest converts a full vendor payload into an update that touches every field
+func (s *CreateWaakyeVendorSchema) toUpdateRequest() *models.UpdateVendorRequest {
+	return &models.UpdateVendorRequest{
+		Name:           &s.Name,
+		Description:    &s.Description,
+		OperatingHours: &s.OperatingHours,
+		ImageID:        &s.ImageID,
+		PhoneNumber:    &s.PhoneNumber,
+		Location: &models.UpdateLocationRequest{
+			StreetAddress: &s.Location.StreetAddress,
+			City:          &s.Location.City,
+			Region:        &s.Location.Region,
+			Latitude:      s.Location.Latitude,
+			Longitude:     s.Location.Longitude,
+			Landmark:      &s.Location.Landmark,
+		},
+	}
+}
 
+// toVendor converts a validated payload into a new vendor
+func (s *CreateWaakyeVendorSchema) toVendor() *models.WaakyeVendor {
+	return &models.WaakyeVendor{
+		Name:           s.Name,
+		Description:    s.Description,
+		OperatingHours: s.OperatingHours,
+		ImageID:        parseImageID(s.ImageID),
+		PhoneNumber:    s.PhoneNumber,
+		Location: models.Location{
+			StreetAddress: s.Location.StreetAddress,
+			City:          s.Location.City,
+			Region:        s.Location.Region,
+			Latitude:      *s.Location.Latitude,
+			Longitude:     *s.Location.Longitude,
+			Landmark:      s.Location.Landmark,
+		},
+	}
+}
 
-type RateVendorRequest struct {
-	HygieneRating int    `json:"hygeine_rating" binding:"required"`
-	ValueRating   int    `json:"value_rating" validate:"required,gte=1,lte=5" db:"value_rating"`
-	TasteRating   int    `json:"taste_rating" validate:"required,gte=1,lte=5" db:"taste_rating"`
-	ServiceRating int    `json:"service_rating" validate:"required,gte=1,lte=5" db:"service_rating"`
-	Comment       string `json:"comment" db:"comment"`
-}
\ No newline at end of file
+// parseImageID reads an already validated, optional upload ID
+func parseImageID(imageID string) *uuid.UUID {
+	id, err := uuid.Parse(imageID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
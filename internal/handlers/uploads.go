@@ -1,99 +1,259 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
-	"time"
 
+	"github.com/aglili/waakye-directory/internal/images"
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
 	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
+// allowedImageTypes maps the image types accepted for upload to the extension they are stored
+// with. HEIC photos from phones are often detected by their HEIF container type.
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/heic": ".heic",
+	"image/heif": ".heic",
+}
+
 type UploadHandler struct {
-	uploadPath string
+	repository postgres.UploadRepository
+	storage    storage.Storage
 }
 
-func NewUploadHandler(uploadPath string) *UploadHandler {
+func NewUploadHandler(repository postgres.UploadRepository, store storage.Storage) *UploadHandler {
 	return &UploadHandler{
-		uploadPath: uploadPath,
+		repository: repository,
+		storage:    store,
 	}
 }
 
 // UploadFile godoc
 // @Summary Upload a file
-// @Description Upload a file to the server (max size: 10MB)
+// @Description Upload a JPEG, PNG, WebP or HEIC image (max size: 10MB). The type is detected from the file's
+// @Description contents, not its name. The returned id can be attached to a review as a photo.
+// @Description The GPS location is stripped from the photo, and JPEG, PNG and WebP images get resized JPEG variants
+// @Description (thumb, small, medium, large) returned as variants along with a srcset.
+// @Description Files are stored under the SHA-256 of the stored file, taken after the GPS location is removed, so
+// @Description re-uploading an identical file returns duplicate: true and shares the file already stored.
 // @Tags uploads
 // @Accept multipart/form-data
 // @Produce json
+// @Security BearerAuth
 // @Param file formData file true "File to upload (max 10MB)"
 // @Success 200 {object} UploadResponse  "File uploaded successfully"
 // @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 415 {object} BadRequestResponse "Not a JPEG, PNG, WebP or HEIC image"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/uploads [post]
 func (h *UploadHandler) UploadFile(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
 	// Set maximum file size (10MB)
 	const maxSize = 10 << 20 // 10 MB in bytes
 
-	// Check if upload directory exists, create if needed
-	if _, err := os.Stat(h.uploadPath); os.IsNotExist(err) {
-		err := os.MkdirAll(h.uploadPath, 0755)
-		if err != nil {
-			utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to upload file")
-			return
-		}
-	}
-
 	// Limit request body size
 	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize)
 
-	file, header, err := ctx.Request.FormFile("file")
-	if err != nil {
-		// Check if it's a file size error
-		if strings.Contains(err.Error(), "body size limit exceeded") {
-			utils.RespondWithBadRequest(ctx,"File too large", "Maximum file size is 10MB")
+	// Only 1MB of the form is kept in memory; a larger file is written to a temporary file,
+	// which is removed once the upload is handled
+	const maxMemory = 1 << 20
+	if err := ctx.Request.ParseMultipartForm(maxMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.RespondWithBadRequest(ctx, "File too large", "Maximum file size is 10MB")
 			return
 		}
 
-		utils.RespondWithBadRequest(ctx, "File too large", "Failed to upload file")
+		utils.RespondWithBadRequest(ctx, err.Error(), "Failed to upload file")
+		return
+	}
+	defer ctx.Request.MultipartForm.RemoveAll()
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		utils.RespondWithBadRequest(ctx, err.Error(), "Failed to upload file")
 		return
 	}
 	defer file.Close()
 
 	// Double check file size from header
 	if header.Size > maxSize {
-		utils.RespondWithBadRequest(ctx,"File too large", "Maximum file size is 10MB")
+		utils.RespondWithBadRequest(ctx, "File too large", "Maximum file size is 10MB")
 		return
 	}
 
-	originalFileName := header.Filename
-	fileExt := filepath.Ext(originalFileName)
-	uniqueName := fmt.Sprintf("%d%s", time.Now().UnixNano(), fileExt)
-	fullPath := filepath.Join(h.uploadPath, uniqueName)
-
-	dst, err := os.Create(fullPath)
+	// The type and extension come from the file's magic bytes, never from the client's filename
+	// or Content-Type, so nothing but images is ever served back from /uploads
+	detected, err := mimetype.DetectReader(file)
 	if err != nil {
-		utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to upload file")
+		utils.RespondWithBadRequest(ctx, err.Error(), "Failed to upload file")
+		return
+	}
+	contentType, _, _ := strings.Cut(detected.String(), ";")
+	fileExt, ok := allowedImageTypes[contentType]
+	if !ok {
+		devMessage := fmt.Sprintf("detected content type %q is not allowed", contentType)
+		utils.RespondWithUnsupportedMediaType(ctx, devMessage, "Only JPEG, PNG, WebP and HEIC images can be uploaded")
 		return
 	}
-	defer dst.Close()
 
-	if err = ctx.SaveUploadedFile(header, fullPath); err != nil {
-		utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to upload file")
-		os.Remove(fullPath)
+	// The GPS location is stripped before the file is hashed, so the checksum is of what gets
+	// stored. The file is streamed through the hash and stored under it, so identical uploads
+	// share one stored file.
+	scrubbed := images.Scrub(file, header.Size, contentType)
+	hash := sha256.New()
+	if _, err := io.Copy(hash, scrubbed.Open()); err != nil {
+		utils.RespondWithBadRequest(ctx, err.Error(), "Failed to upload file")
 		return
 	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
 
-	fileURL := fmt.Sprintf("/uploads/%s", uniqueName)
+	fileName := checksum + fileExt
+	upload := models.Upload{
+		OwnerID:      &userID,
+		FileName:     fileName,
+		OriginalName: header.Filename,
+		Checksum:     checksum,
+	}
+
+	// Only the first upload of a file renders variants and stores anything; its processing
+	// errors are the client's fault, unlike storage errors
+	var processErr error
+	store := func(upload *models.Upload) error {
+		processed, err := images.Process(scrubbed)
+		if err != nil {
+			processErr = err
+			return err
+		}
 
-	response := map[string]string{
-		"file_url":  fmt.Sprintf("%s%s", ctx.Request.Host, fileURL),
-		"file_name": originalFileName,
-		"file_size": fmt.Sprintf("%d", header.Size),
-		"file_type": header.Header.Get("Content-Type"),
+		upload.ContentType = contentType
+		upload.SizeBytes = scrubbed.Size()
+		upload.Variants = models.ImageVariants{}
+		if processed.Width > 0 {
+			upload.Width = &processed.Width
+			upload.Height = &processed.Height
+		}
+
+		var written []string
+		write := func(key, contentType string, contents io.Reader, size int64) error {
+			if err := h.storage.Put(ctx, key, contents, size, contentType); err != nil {
+				for _, key := range written {
+					if err := h.storage.Delete(ctx, key); err != nil {
+						log.Error().Err(err).Str("key", key).Msg("Failed to remove stored file")
+					}
+				}
+				return err
+			}
+			written = append(written, key)
+			return nil
+		}
+
+		if err := write(fileName, contentType, scrubbed.Open(), scrubbed.Size()); err != nil {
+			return err
+		}
+		for _, variant := range processed.Variants {
+			variantName := models.VariantKey(fileName, variant.Name)
+			if err := write(variantName, "image/jpeg", bytes.NewReader(variant.Data), int64(len(variant.Data))); err != nil {
+				return err
+			}
+			upload.Variants[variant.Name] = models.ImageVariant{
+				Width:  variant.Width,
+				Height: variant.Height,
+			}
+		}
+
+		return nil
+	}
+
+	duplicate, err := h.repository.CreateUpload(ctx, &upload, store)
+	if err != nil {
+		switch {
+		case errors.Is(processErr, images.ErrTooManyPixels):
+			utils.RespondWithBadRequest(ctx, err.Error(), "Images can be at most 50 megapixels")
+		case processErr != nil:
+			utils.RespondWithBadRequest(ctx, err.Error(), "The image could not be read")
+		default:
+			utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to upload file")
+		}
+		return
+	}
+
+	upload.ResolveURLs(h.storage.URL)
+	variantURLs := make(map[string]string, len(upload.Variants))
+	for name, variant := range upload.Variants {
+		variantURLs[name] = variant.URL
+	}
+
+	response := gin.H{
+		"id":        upload.ID.String(),
+		"file_url":  upload.FileURL,
+		"file_name": upload.OriginalName,
+		"file_size": fmt.Sprintf("%d", upload.SizeBytes),
+		"file_type": upload.ContentType,
+		"checksum":  upload.Checksum,
+		"width":     upload.Width,
+		"height":    upload.Height,
+		"variants":  variantURLs,
+		"srcset":    upload.Variants.Srcset(),
+		"duplicate": duplicate,
 	}
 
 	utils.RespondWithOK(ctx, "File uploaded successfully", response)
 }
+
+// ServeUpload godoc
+// @Summary Download an uploaded file
+// @Description Stream a stored upload. Only routed when uploads are kept outside the local filesystem, so the
+// @Description default public URL works with every storage backend.
+// @Tags uploads
+// @Produce octet-stream
+// @Param key path string true "Stored file name"
+// @Success 200 {file} file "The file"
+// @Failure 404 {object} NotFoundResponse "File not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /uploads/{key} [get]
+func (h *UploadHandler) ServeUpload(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+
+	file, err := h.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithNotFound(ctx, err.Error(), "File does not exist")
+			return
+		}
+		utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to get file")
+		return
+	}
+	defer file.Close()
+
+	// Only images are ever stored, and browsers should not be talked into treating them as anything else
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	userRepository postgres.UserRepository
+}
+
+func NewUserHandler(userRepository postgres.UserRepository) *UserHandler {
+	return &UserHandler{
+		userRepository: userRepository,
+	}
+}
+
+// UpdateUserRole godoc
+// @Summary Change a user's role
+// @Description Grant a user the admin, moderator, vendor_owner or contributor role (admin only). The new role applies from the user's next access token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param role body models.UpdateUserRoleRequest true "New role"
+// @Success 200 {object} CreatedResponse "User role updated successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Admin role required"
+// @Failure 404 {object} NotFoundResponse "User not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.UpdateUserRoleRequest
+	if !utils.BindJSON(ctx, &request, "Failed to update user role") {
+		return
+	}
+
+	// Stop admins from locking themselves out
+	if currentUserID, _ := middleware.CurrentUserID(ctx); currentUserID == parsedUUID {
+		userMessage := "You cannot change your own role"
+		utils.RespondWithBadRequest(ctx, "attempted to change own role", userMessage)
+		return
+	}
+
+	if err := h.userRepository.UpdateUserRole(ctx, parsedUUID, request.Role); err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			userMessage := "User does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to update user role"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	user, err := h.userRepository.GetUserByID(ctx, parsedUUID)
+	if err != nil {
+		userMessage := "Failed to update user role"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	updatedMessage := "User role updated successfully"
+	utils.RespondWithOK(ctx, updatedMessage, user)
+}
@@ -1,88 +1,172 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/hours"
+	"github.com/aglili/waakye-directory/internal/middleware"
 	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/moderation"
 	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
 	"github.com/aglili/waakye-directory/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 type VendorHandler struct {
-	repository        postgres.VendorRepository
-	ratingsRepository postgres.RatingsRepository
+	repository           postgres.VendorRepository
+	ratingsRepository    postgres.RatingsRepository
+	hoursRepository      postgres.HoursRepository
+	moderationRepository postgres.ModerationRepository
+	// wordlist flags new and edited review comments for moderators
+	wordlist *moderation.Wordlist
+	// ranking holds the method, minimum review count and prior weight top rated listings default to
+	ranking models.TopRatedParams
+	// storage builds the URLs of vendor images and review photos from their keys
+	storage storage.Storage
 }
 
-func NewVendorHandler(repository postgres.VendorRepository, ratingsRepository postgres.RatingsRepository) *VendorHandler {
+func NewVendorHandler(repository postgres.VendorRepository, ratingsRepository postgres.RatingsRepository, hoursRepository postgres.HoursRepository, moderationRepository postgres.ModerationRepository, wordlist *moderation.Wordlist, ranking models.TopRatedParams, store storage.Storage) *VendorHandler {
 	return &VendorHandler{
-		repository:        repository,
-		ratingsRepository: ratingsRepository,
+		repository:           repository,
+		ratingsRepository:    ratingsRepository,
+		hoursRepository:      hoursRepository,
+		moderationRepository: moderationRepository,
+		wordlist:             wordlist,
+		ranking:              ranking,
+		storage:              store,
 	}
 }
 
 // CreateVendor godoc
 // @Summary Create a new vendor
-// @Description Create a new waakye vendor
+// @Description Create a new waakye vendor. Its photo is given as image_id, an image the caller uploaded through /api/v1/uploads.
 // @Tags vendors
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param vendor body CreateWaakyeVendorSchema true "Vendor object"
 // @Success 201 {object} CreatedResponse "Vendor created successfully"
 // @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed or image_id is not your image"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors [post]
 func (h *VendorHandler) CreateVendor(ctx *gin.Context) {
-	var vendor models.WaakyeVendor
-	if err := ctx.ShouldBindJSON(&vendor); err != nil {
-		userMessage := "Failed to create vendor"
-		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	var request CreateWaakyeVendorSchema
+	if !utils.BindJSON(ctx, &request, "Failed to create vendor") {
 		return
 	}
+	vendor := request.toVendor()
+	vendor.CreatedBy = &userID
 
-	if err := h.repository.CreateVendor(ctx, &vendor); err != nil {
+	// Owners creating their own listing manage it from the start
+	if role, _ := middleware.CurrentRole(ctx); role == models.RoleVendorOwner {
+		vendor.OwnerID = &userID
+	}
+
+	if err := h.repository.CreateVendor(ctx, vendor); err != nil {
 		userMessage := "Failed to create vendor"
+		if errors.Is(err, postgres.ErrInvalidVendorImage) {
+			respondWithInvalidVendorImage(ctx, err, userMessage)
+			return
+		}
 		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
 		return
 	}
 
+	// New listings always start the verification workflow as pending
+	vendor.IsVerified = vendor.VerificationStatus == models.VerificationVerified
+
+	// Structured hours are best effort; owners can correct them through the hours endpoints
+	if spans, err := hours.Parse(vendor.OperatingHours); err == nil {
+		if err := h.hoursRepository.ReplaceWeeklyHours(ctx, vendor.ID, spans); err != nil {
+			log.Warn().Err(err).Str("vendor_id", vendor.ID.String()).Msg("Failed to store parsed operating hours")
+		} else {
+			schedule := hours.Schedule{Weekly: spans}
+			now := time.Now()
+			open := schedule.IsOpenAt(now)
+			vendor.IsOpenNow = &open
+			vendor.NextOpenAt = schedule.NextOpenAt(now)
+		}
+	}
+
+	vendor.ResolveURLs(h.storage.URL)
+
 	createdMessage := "Vendor created successfully"
 	utils.RespondWithCreated(ctx, createdMessage, vendor)
 }
 
 // ListVendorsWithPagination godoc
 // @Summary List vendors with pagination
-// @Description List all vendors with pagination
+// @Description List vendors with optional filters and sorting
 // @Tags vendors
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
+// @Param cursor query string false "Keyset cursor from next_cursor or prev_cursor; send it empty to start cursor pagination"
+// @Param city query string false "Only vendors in this city (exact match)"
+// @Param region query string false "Only vendors in this region (exact match)"
+// @Param is_verified query bool false "Only verified (true) or unverified (false) vendors"
+// @Param min_rating query number false "Minimum average rating (0-5)"
+// @Param has_image query bool false "Only vendors with (true) or without (false) a photo"
+// @Param open_now query bool false "Only vendors open (true) or closed (false) right now, in Accra time"
+// @Param sort query string false "Sort order: newest, rating, name, reviews or distance" default(newest)
+// @Param lat query float64 false "Latitude, required to sort by distance"
+// @Param lng query float64 false "Longitude, required to sort by distance"
 // @Success 200 {object} PaginatedResponse "Vendors retrieved successfully"
 // @Failure 400 {object} BadRequestResponse "Bad request"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors [get]
 func (h *VendorHandler) ListVendorsWithPagination(ctx *gin.Context) {
-	params, err := utils.GetPaginationParams(ctx)
+	params, err := parseVendorListParams(ctx)
 	if err != nil {
 		userMessage := "Failed to list vendors with pagination"
 		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
 		return
 	}
 
-	vendors, err := h.repository.ListVendorsWithPagination(ctx, params.Page, params.PageSize)
+	var vendors []models.WaakyeVendor
+	var keyset *models.KeysetPage
+	if params.UseCursor {
+		vendors, keyset, err = h.repository.ListVendorsAfter(ctx, *params)
+	} else {
+		vendors, err = h.repository.ListVendorsWithPagination(ctx, *params)
+	}
 	if err != nil {
 		userMessage := "Failed to list vendors with pagination"
-		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		respondWithListError(ctx, err, userMessage)
 		return
 	}
 
-	totalItems, err := h.repository.CountVendors(ctx)
+	totalItems, err := h.repository.CountVendors(ctx, params.Filter)
 	if err != nil {
 		userMessage := "Failed to list vendors with pagination"
 		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
 		return
 	}
 
+	for i := range vendors {
+		vendors[i].ResolveURLs(h.storage.URL)
+	}
+
 	getMessage := "Vendors retrieved successfully"
+	if params.UseCursor {
+		utils.SendCursorPaginatedResponse(ctx, vendors, params.PageSize, totalItems, keyset, getMessage)
+		return
+	}
 	utils.SendPaginatedResponse(ctx, vendors, params.Page, params.PageSize, totalItems, getMessage)
 }
 
@@ -95,6 +179,7 @@ func (h *VendorHandler) ListVendorsWithPagination(ctx *gin.Context) {
 // @Param id path string true "Vendor ID"
 // @Success 200 {object} CreatedResponse "Vendor retrieved successfully"
 // @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors/{id} [get]
 func (h *VendorHandler) GetVendorByID(ctx *gin.Context) {
@@ -105,142 +190,327 @@ func (h *VendorHandler) GetVendorByID(ctx *gin.Context) {
 
 	vendor, err := h.repository.GetVendorByID(ctx, parsedUUID)
 	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
 		userMessage := "Failed to get vendor"
 		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
 		return
 	}
 
+	vendor.ResolveURLs(h.storage.URL)
+
 	getMessage := "Vendor retrieved successfully"
 	utils.RespondWithOK(ctx, getMessage, vendor)
 }
 
+// Bounds on the nearby search
+const (
+	defaultNearbyRadiusKm = 5.0
+	maxNearbyRadiusKm     = 50.0
+	defaultNearestLimit   = 10
+	maxNearbyLimit        = 100
+)
 
 // GetNearbyVendors godoc
 // @Summary Get nearby vendors
-// @Description Get nearby vendors based on latitude and longitude
+// @Description Get vendors around a point, closest first. By default every vendor within radius_km is returned;
+// @Description with mode=nearest the limit closest vendors are returned regardless of radius.
 // @Tags vendors
 // @Accept json
 // @Produce json
 // @Param lat query float64 true "Latitude"
 // @Param lng query float64 true "Longitude"
-// @Success 200 {object} CreatedResponse "Nearby vendors retrieved successfully"
-// @Failure 400 {object} BadRequestResponse "Invalid latitude or longitude"
+// @Param radius_km query number false "Search radius in kilometres, up to 50" default(5)
+// @Param mode query string false "radius or nearest" default(radius)
+// @Param limit query int false "Maximum number of vendors across all pages, up to 100 (nearest mode defaults to 10)"
+// @Param open_now query bool false "Only vendors open (true) or closed (false) right now"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} PaginatedResponse "Nearby vendors retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid query parameters"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors/nearby [get]
 func (h *VendorHandler) GetNearbyVendors(ctx *gin.Context) {
-	lat, ok := utils.ParseFloat64(ctx, "lat")
+	lat, ok := utils.ParseLatitude(ctx, "lat")
 	if !ok {
 		return
 	}
 
-	lng, ok := utils.ParseFloat64(ctx, "lng")
+	lng, ok := utils.ParseLongitude(ctx, "lng")
 	if !ok {
 		return
 	}
 
-	vendors, err := h.repository.GetNearbyVendors(ctx, lat, lng, 5)
+	params, err := parseNearbyParams(ctx)
+	if err != nil {
+		userMessage := "Failed to get nearby vendors"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+	params.Latitude, params.Longitude = lat, lng
+
+	vendors, err := h.repository.GetNearbyVendors(ctx, *params)
+	if err != nil {
+		userMessage := "Failed to get nearby vendors"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountNearbyVendors(ctx, *params)
 	if err != nil {
 		userMessage := "Failed to get nearby vendors"
 		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
 		return
 	}
 
+	for i := range vendors {
+		vendors[i].ResolveURLs(h.storage.URL)
+	}
+
 	getMessage := "Nearby vendors retrieved successfully"
-	utils.RespondWithOK(ctx, getMessage, vendors)
+	utils.SendPaginatedResponse(ctx, vendors, params.Page, params.PageSize, totalItems, getMessage)
+}
+
+// parseNearbyParams reads everything about a nearby search except the point itself
+func parseNearbyParams(ctx *gin.Context) (*models.NearbyParams, error) {
+	pagination, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &models.NearbyParams{
+		RadiusKm: defaultNearbyRadiusKm,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+	}
+
+	switch mode := ctx.DefaultQuery("mode", "radius"); mode {
+	case "radius":
+	case "nearest":
+		params.Nearest = true
+		params.Limit = defaultNearestLimit
+	default:
+		return nil, errors.New("'mode' must be radius or nearest")
+	}
+
+	radius, err := utils.ParseOptionalFloat64(ctx, "radius_km", 0.1, maxNearbyRadiusKm)
+	if err != nil {
+		return nil, err
+	}
+	if radius != nil {
+		params.RadiusKm = *radius
+	}
+
+	limit, err := utils.ParseOptionalInt(ctx, "limit", 1, maxNearbyLimit)
+	if err != nil {
+		return nil, err
+	}
+	if limit != nil {
+		params.Limit = *limit
+	}
+
+	if params.Filter.OpenNow, err = utils.ParseOptionalBool(ctx, "open_now"); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// SearchVendors godoc
+// @Summary Search vendors
+// @Description Full text and fuzzy search over vendor names, descriptions, landmarks, streets and cities.
+// @Description Results are ranked by relevance. Pass lat and lng to boost vendors close to you.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text, e.g. auntie muni or near Accra Mall"
+// @Param lat query float64 false "Latitude for proximity boosting"
+// @Param lng query float64 false "Longitude for proximity boosting"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} PaginatedResponse "Vendors retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/search [get]
+func (h *VendorHandler) SearchVendors(ctx *gin.Context) {
+	params, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		userMessage := "Failed to search vendors"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	searchQuery := strings.TrimSpace(ctx.Query("q"))
+	if len(searchQuery) < 2 || len(searchQuery) > 100 {
+		userMessage := "Search text must be between 2 and 100 characters"
+		utils.RespondWithBadRequest(ctx, "invalid 'q' length", userMessage)
+		return
+	}
+
+	searchParams := models.VendorSearchParams{
+		Query:    searchQuery,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}
+
+	// Proximity boosting is optional but needs both coordinates
+	_, hasLat := ctx.GetQuery("lat")
+	_, hasLng := ctx.GetQuery("lng")
+	if hasLat || hasLng {
+		lat, ok := utils.ParseLatitude(ctx, "lat")
+		if !ok {
+			return
+		}
+		lng, ok := utils.ParseLongitude(ctx, "lng")
+		if !ok {
+			return
+		}
+		searchParams.Latitude = &lat
+		searchParams.Longitude = &lng
+	}
+
+	vendors, err := h.repository.SearchVendors(ctx, searchParams)
+	if err != nil {
+		userMessage := "Failed to search vendors"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountSearchVendors(ctx, searchParams)
+	if err != nil {
+		userMessage := "Failed to search vendors"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	for i := range vendors {
+		vendors[i].ResolveURLs(h.storage.URL)
+	}
+
+	getMessage := "Vendors retrieved successfully"
+	utils.SendPaginatedResponse(ctx, vendors, params.Page, params.PageSize, totalItems, getMessage)
 }
 
 // GetVerifiedVendors godoc
 // @Summary Get verified vendors
-// @Description Get all verified vendors
+// @Description Get verified vendors. Accepts the same filters and sorting as the vendor list.
 // @Tags vendors
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
+// @Param cursor query string false "Keyset cursor from next_cursor or prev_cursor; send it empty to start cursor pagination"
+// @Param city query string false "Only vendors in this city (exact match)"
+// @Param region query string false "Only vendors in this region (exact match)"
+// @Param min_rating query number false "Minimum average rating (0-5)"
+// @Param has_image query bool false "Only vendors with (true) or without (false) a photo"
+// @Param open_now query bool false "Only vendors open (true) or closed (false) right now, in Accra time"
+// @Param sort query string false "Sort order: newest, rating, name, reviews or distance" default(newest)
+// @Param lat query float64 false "Latitude, required to sort by distance"
+// @Param lng query float64 false "Longitude, required to sort by distance"
 // @Success 200 {object} PaginatedResponse "Verified vendors retrieved successfully"
 // @Failure 400 {object} BadRequestResponse "Bad request"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors/verified [get]
 func (h *VendorHandler) GetVerifiedVendors(ctx *gin.Context) {
-	params, err := utils.GetPaginationParams(ctx)
+	params, err := parseVendorListParams(ctx)
 	if err != nil {
 		userMessage := "Failed to list verified vendors"
 		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
 		return
 	}
 
-	vendors, err := h.repository.GetVerifiedVendors(ctx, params.Page, params.PageSize)
+	var vendors []models.WaakyeVendor
+	var keyset *models.KeysetPage
+	if params.UseCursor {
+		vendors, keyset, err = h.repository.GetVerifiedVendorsAfter(ctx, *params)
+	} else {
+		vendors, err = h.repository.GetVerifiedVendors(ctx, *params)
+	}
 	if err != nil {
 		userMessage := "Failed to list verified vendors"
-		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		respondWithListError(ctx, err, userMessage)
 		return
 	}
 
-	totalItems, err := h.repository.CountVerifiedVendors(ctx)
+	totalItems, err := h.repository.CountVerifiedVendors(ctx, params.Filter)
 	if err != nil {
 		userMessage := "Failed to list verified vendors"
 		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
 		return
 	}
 
+	for i := range vendors {
+		vendors[i].ResolveURLs(h.storage.URL)
+	}
+
 	getMessage := "Verified vendors retrieved successfully"
+	if params.UseCursor {
+		utils.SendCursorPaginatedResponse(ctx, vendors, params.PageSize, totalItems, keyset, getMessage)
+		return
+	}
 	utils.SendPaginatedResponse(ctx, vendors, params.Page, params.PageSize, totalItems, getMessage)
 }
 
 // RateVendor godoc
 // @Summary Rate a vendor
-// @Description Rate a vendor by ID
+// @Description Rate a vendor by ID. Each user has one rating per vendor, so rating the same vendor again updates it.
+// @Description Up to five photos uploaded through /api/v1/uploads can be attached with photo_ids.
 // @Tags vendors
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path string true "Vendor ID"
-// @Param rating body RateVendorRequest true "Rating object"
+// @Param rating body models.RateVendorRequest true "Rating object"
+// @Success 200 {object} CreatedResponse "Vendor rating updated successfully"
 // @Success 201 {object} CreatedResponse "Vendor rated successfully"
 // @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
 // @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors/{id}/rate [post]
 func (h *VendorHandler) RateVendor(ctx *gin.Context) {
-	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	userID, ok := middleware.CurrentUserID(ctx)
 	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
 		return
 	}
 
-	var request models.RateVendorRequest
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		userMessage := "Failed to rate vendor"
-		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
 		return
 	}
 
-	// check if vendor exists
-	vendor, err := h.repository.GetVendorByID(ctx, parsedUUID)
-	if err != nil {
-		userMessage := "Failed to rate vendor"
-		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+	var request models.RateVendorRequest
+	if !utils.BindJSON(ctx, &request, "Failed to rate vendor") {
 		return
 	}
 
-	if vendor.ID == (models.WaakyeVendor{}).ID {
-		userMessage := "Vendor does not exist"
-		utils.RespondWithNotFound(ctx, userMessage, userMessage)
+	rating, created, err := h.ratingsRepository.RateVendor(ctx, parsedUUID, userID, &request)
+	if err != nil {
+		respondWithRatingError(ctx, err, "Failed to rate vendor")
 		return
 	}
+	h.flagOffensiveReview(ctx, rating)
+	rating.ResolveURLs(h.storage.URL)
 
-	if err := h.ratingsRepository.RateVendor(ctx,parsedUUID,&request); err != nil {
-		userMessage := "Failed to rate vendor"
-		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+	if !created {
+		updatedMessage := "Vendor rating updated successfully"
+		utils.RespondWithOK(ctx, updatedMessage, rating)
 		return
 	}
 
 	ratedMessage := "Vendor rated successfully"
-	utils.RespondWithCreated(ctx, ratedMessage, request)
-
+	utils.RespondWithCreated(ctx, ratedMessage, rating)
 }
 
 // GetVendorRatings godoc
 // @Summary Get vendor ratings
-// @Description Get ratings for a vendor by ID
+// @Description Get a vendor's average ratings, star histograms and latest reviews. Use /reviews for the full list.
 // @Tags vendors
 // @Accept json
 // @Produce json
@@ -262,34 +532,619 @@ func (h *VendorHandler) GetVendorRatings(ctx *gin.Context) {
 		return
 	}
 
+	ratings.ResolveURLs(h.storage.URL)
+
 	getMessage := "Vendor ratings retrieved successfully"
 	utils.RespondWithOK(ctx, getMessage, ratings)
 }
 
+// GetVendorRatingStats godoc
+// @Summary Get vendor rating stats
+// @Description Get a vendor's 1-5 star histogram for every rating dimension and its average ratings per week or
+// @Description month, so improvement or decline over time is visible. Dates are in Africa/Accra time and weeks
+// @Description start on Monday. Buckets without ratings have null averages.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param id path string true "Vendor ID"
+// @Param bucket query string false "Bucket size: week or month" default(week)
+// @Param from query string false "First day, YYYY-MM-DD. Defaults to 12 buckets before to"
+// @Param to query string false "Last day, YYYY-MM-DD. Defaults to today"
+// @Success 200 {object} CreatedResponse "Vendor rating stats retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/ratings/stats [get]
+func (h *VendorHandler) GetVendorRatingStats(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	params, err := parseRatingTrendParams(ctx, time.Now())
+	if err != nil {
+		userMessage := "Failed to get vendor rating stats"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	stats, err := h.ratingsRepository.GetRatingStats(ctx, parsedUUID, *params)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to get vendor rating stats"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	getMessage := "Vendor rating stats retrieved successfully"
+	utils.RespondWithOK(ctx, getMessage, stats)
+}
+
+const (
+	defaultTrendBuckets = 12
+	maxTrendBuckets     = 120
+)
+
+// parseRatingTrendParams reads the bucket and date range of a rating trend, defaulting to the
+// last defaultTrendBuckets buckets up to today
+func parseRatingTrendParams(ctx *gin.Context, now time.Time) (*models.RatingTrendParams, error) {
+	params := &models.RatingTrendParams{
+		Bucket: models.RatingTrendBucket(ctx.DefaultQuery("bucket", string(models.RatingTrendWeek))),
+	}
+	if !params.Bucket.Valid() {
+		return nil, errors.New("'bucket' must be week or month")
+	}
+
+	// bucketsBack moves a date by whole buckets
+	bucketsBack := func(date time.Time, buckets int) time.Time {
+		if params.Bucket == models.RatingTrendMonth {
+			return date.AddDate(0, -buckets, 0)
+		}
+		return date.AddDate(0, 0, -7*buckets)
+	}
+
+	params.To = hours.Date(now)
+	if to := ctx.Query("to"); to != "" {
+		parsed, err := time.ParseInLocation(hours.DateLayout, to, hours.Accra)
+		if err != nil {
+			return nil, errors.New("'to' must be a date formatted as YYYY-MM-DD")
+		}
+		params.To = parsed
+	}
+
+	params.From = bucketsBack(params.To, defaultTrendBuckets).AddDate(0, 0, 1)
+	if from := ctx.Query("from"); from != "" {
+		parsed, err := time.ParseInLocation(hours.DateLayout, from, hours.Accra)
+		if err != nil {
+			return nil, errors.New("'from' must be a date formatted as YYYY-MM-DD")
+		}
+		params.From = parsed
+	}
+
+	if params.From.After(params.To) {
+		return nil, errors.New("'from' must not be after 'to'")
+	}
+	if params.From.Before(bucketsBack(params.To, maxTrendBuckets)) {
+		return nil, fmt.Errorf("the range may span at most %d buckets", maxTrendBuckets)
+	}
+
+	return params, nil
+}
+
+// ListVendorReviews godoc
+// @Summary List vendor reviews
+// @Description List a vendor's reviews with their author, per-dimension scores and comment. Pages by offset
+// @Description unless a cursor is sent.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Param id path string true "Vendor ID"
+// @Param sort query string false "Sort order: newest, highest, lowest or most_helpful" default(newest)
+// @Param min_stars query int false "Only reviews whose overall score is at least this many stars (1-5)"
+// @Param has_comment query bool false "Only reviews with (true) or without (false) a comment"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Param cursor query string false "Keyset cursor from next_cursor or prev_cursor; send it empty to start cursor pagination"
+// @Success 200 {object} PaginatedResponse "Vendor reviews retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/reviews [get]
+func (h *VendorHandler) ListVendorReviews(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	params, err := parseReviewListParams(ctx)
+	if err != nil {
+		userMessage := "Failed to list vendor reviews"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	var reviews []models.Review
+	var keyset *models.KeysetPage
+	if params.UseCursor {
+		reviews, keyset, err = h.ratingsRepository.ListVendorReviewsAfter(ctx, parsedUUID, *params)
+	} else {
+		reviews, err = h.ratingsRepository.ListVendorReviews(ctx, parsedUUID, *params)
+	}
+	if err != nil {
+		userMessage := "Failed to list vendor reviews"
+		respondWithListError(ctx, err, userMessage)
+		return
+	}
+
+	totalItems, err := h.ratingsRepository.CountVendorReviews(ctx, parsedUUID, params.Filter)
+	if err != nil {
+		userMessage := "Failed to list vendor reviews"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	for i := range reviews {
+		reviews[i].ResolveURLs(h.storage.URL)
+	}
+
+	getMessage := "Vendor reviews retrieved successfully"
+	if params.UseCursor {
+		utils.SendCursorPaginatedResponse(ctx, reviews, params.PageSize, totalItems, keyset, getMessage)
+		return
+	}
+	utils.SendPaginatedResponse(ctx, reviews, params.Page, params.PageSize, totalItems, getMessage)
+}
+
+// parseReviewListParams reads the sort, filters and page of a review listing
+func parseReviewListParams(ctx *gin.Context) (*models.ReviewListParams, error) {
+	pagination, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &models.ReviewListParams{
+		Sort:      models.ReviewSort(ctx.Query("sort")),
+		Page:      pagination.Page,
+		PageSize:  pagination.PageSize,
+		UseCursor: pagination.UseCursor,
+		Cursor:    pagination.Cursor,
+	}
+
+	if params.Filter.MinStars, err = utils.ParseOptionalInt(ctx, "min_stars", 1, 5); err != nil {
+		return nil, err
+	}
+	if params.Filter.HasComment, err = utils.ParseOptionalBool(ctx, "has_comment"); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
 // GetTopRatedVendors godoc
 // @Summary Get top rated vendors
-// @Description Get all top rated vendors
+// @Description Rank vendors by a score that accounts for how many ratings they have, so one 5 star rating
+// @Description does not beat hundreds averaging 4.8. The bayesian method pulls every average towards the
+// @Description mean of the city or region being ranked; the wilson method uses the lower bound of a 95%
+// @Description confidence interval. rank_score is on the same 1-5 scale as the ratings.
 // @Tags vendors
 // @Accept json
 // @Produce json
-// @Success 200 {object} CreatedResponse "Top rated vendors retrieved successfully"
+// @Param method query string false "Ranking method: bayesian or wilson"
+// @Param dimension query string false "Rank by overall, taste, hygiene, value or service" default(overall)
+// @Param min_reviews query int false "Minimum number of ratings a vendor needs to be ranked"
+// @Param city query string false "Only rank vendors in this city (exact match)"
+// @Param region query string false "Only rank vendors in this region (exact match)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} PaginatedResponse "Top rated vendors retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
 // @Failure 500 {object} InternalServerErrorResponse "Internal server error"
 // @Router /api/v1/vendors/top_rated [get]
 func (h *VendorHandler) GetTopRatedVendors(ctx *gin.Context) {
+	params, err := h.parseTopRatedParams(ctx)
+	if err != nil {
+		userMessage := "Failed to get top rated vendors"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
 
-	vendors, err := h.repository.GetTopRatedVendors(ctx)
+	vendors, err := h.repository.GetTopRatedVendors(ctx, *params)
 	if err != nil {
-		userMessage := "Failed to get vendor ratings"
+		userMessage := "Failed to get top rated vendors"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountTopRatedVendors(ctx, *params)
+	if err != nil {
+		userMessage := "Failed to get top rated vendors"
 		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
 		return
 	}
 
+	for i := range vendors {
+		vendors[i].ResolveURLs(h.storage.URL)
+	}
+
 	getMessage := "Top rated vendors retrieved successfully"
-	utils.RespondWithOK(ctx, getMessage, vendors)
+	utils.SendPaginatedResponse(ctx, vendors, params.Page, params.PageSize, totalItems, getMessage)
+}
+
+// maxRankingMinReviews bounds min_reviews so a typo cannot turn into a scan that can never match
+const maxRankingMinReviews = 10000
+
+// parseTopRatedParams reads a top rated listing's query parameters over the configured defaults
+func (h *VendorHandler) parseTopRatedParams(ctx *gin.Context) (*models.TopRatedParams, error) {
+	pagination, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := h.ranking
+	params.Page = pagination.Page
+	params.PageSize = pagination.PageSize
+	params.City = ctx.Query("city")
+	params.Region = ctx.Query("region")
+
+	if method := ctx.Query("method"); method != "" {
+		params.Method = models.RankingMethod(method)
+	}
+	if !params.Method.Valid() {
+		return nil, errors.New("'method' must be bayesian or wilson")
+	}
+
+	params.Dimension = models.RatingDimension(ctx.DefaultQuery("dimension", string(models.RatingDimensionOverall)))
+	if !params.Dimension.Valid() {
+		return nil, errors.New("'dimension' must be overall, taste, hygiene, value or service")
+	}
+
+	minReviews, err := utils.ParseOptionalInt(ctx, "min_reviews", 1, maxRankingMinReviews)
+	if err != nil {
+		return nil, err
+	}
+	if minReviews != nil {
+		params.MinReviews = *minReviews
+	}
+
+	return &params, nil
+}
+
+// ReplaceVendor godoc
+// @Summary Replace a vendor
+// @Description Replace every field of a vendor and its location
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param vendor body CreateWaakyeVendorSchema true "Vendor object"
+// @Success 200 {object} CreatedResponse "Vendor updated successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this vendor"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id} [put]
+func (h *VendorHandler) ReplaceVendor(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request CreateWaakyeVendorSchema
+	if !utils.BindJSON(ctx, &request, "Failed to update vendor") {
+		return
+	}
+
+	if !h.authorizeVendorChange(ctx, parsedUUID, middleware.PermVendorEditOwn, middleware.PermVendorEditAny) {
+		return
+	}
+
+	h.applyVendorUpdate(ctx, parsedUUID, request.toUpdateRequest())
+}
+
+// UpdateVendor godoc
+// @Summary Update a vendor
+// @Description Partially update a vendor and its location. Omitted fields are left unchanged.
+// @Tags vendors
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param vendor body models.UpdateVendorRequest true "Fields to update"
+// @Success 200 {object} CreatedResponse "Vendor updated successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this vendor"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id} [patch]
+func (h *VendorHandler) UpdateVendor(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.UpdateVendorRequest
+	if !utils.BindJSON(ctx, &request, "Failed to update vendor") {
+		return
+	}
+
+	if !h.authorizeVendorChange(ctx, parsedUUID, middleware.PermVendorEditOwn, middleware.PermVendorEditAny) {
+		return
+	}
+
+	h.applyVendorUpdate(ctx, parsedUUID, &request)
+}
+
+// authorizeVendorChange lets staff holding anyPermission change any vendor and owners holding
+// ownPermission change only the listings they own. It writes the error response when refused.
+func (h *VendorHandler) authorizeVendorChange(ctx *gin.Context, vendorID uuid.UUID, ownPermission, anyPermission middleware.Permission) bool {
+	if middleware.HasPermission(ctx, anyPermission) {
+		return true
+	}
+
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return false
+	}
+
+	vendor, err := h.repository.GetVendorByID(ctx, vendorID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return false
+		}
+		userMessage := "Failed to check vendor ownership"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return false
+	}
+
+	if !middleware.HasPermission(ctx, ownPermission) || vendor.OwnerID == nil || *vendor.OwnerID != userID {
+		userMessage := "You can only manage your own vendor listing"
+		utils.RespondWithForbidden(ctx, "user does not own this vendor", userMessage)
+		return false
+	}
+
+	return true
+}
+
+func (h *VendorHandler) applyVendorUpdate(ctx *gin.Context, vendorID uuid.UUID, request *models.UpdateVendorRequest) {
+	editorID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	if err := h.repository.UpdateVendor(ctx, vendorID, editorID, request); err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		if errors.Is(err, postgres.ErrInvalidVendorImage) {
+			respondWithInvalidVendorImage(ctx, err, "Failed to update vendor")
+			return
+		}
+		userMessage := "Failed to update vendor"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	vendor, err := h.repository.GetVendorByID(ctx, vendorID)
+	if err != nil {
+		userMessage := "Failed to update vendor"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	vendor.ResolveURLs(h.storage.URL)
+
+	updatedMessage := "Vendor updated successfully"
+	utils.RespondWithOK(ctx, updatedMessage, vendor)
+}
+
+func respondWithInvalidVendorImage(ctx *gin.Context, err error, userMessage string) {
+	utils.RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, []utils.FieldError{{
+		Field:   "image_id",
+		Rule:    "upload",
+		Message: "must be an image you uploaded through /api/v1/uploads",
+	}})
+}
+
+// DeleteVendor godoc
+// @Summary Delete a vendor
+// @Description Soft delete a vendor. Deleted vendors are hidden from every listing and can be restored by an admin.
+// @Tags vendors
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Success 200 {object} CreatedResponse "Vendor deleted successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Not allowed to manage this vendor"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id} [delete]
+func (h *VendorHandler) DeleteVendor(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if !h.authorizeVendorChange(ctx, parsedUUID, middleware.PermVendorDeleteOwn, middleware.PermVendorDeleteAny) {
+		return
+	}
+
+	if err := h.repository.DeleteVendor(ctx, parsedUUID); err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to delete vendor"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	deletedMessage := "Vendor deleted successfully"
+	utils.RespondWithOK(ctx, deletedMessage, gin.H{"id": parsedUUID})
+}
+
+// RestoreVendor godoc
+// @Summary Restore a deleted vendor
+// @Description Bring back a soft deleted vendor (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Success 200 {object} CreatedResponse "Vendor restored successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Admin role required"
+// @Failure 404 {object} NotFoundResponse "Deleted vendor not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/vendors/{id}/restore [post]
+func (h *VendorHandler) RestoreVendor(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.RestoreVendor(ctx, parsedUUID); err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Deleted vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to restore vendor"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	vendor, err := h.repository.GetVendorByID(ctx, parsedUUID)
+	if err != nil {
+		userMessage := "Failed to restore vendor"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	vendor.ResolveURLs(h.storage.URL)
+
+	restoredMessage := "Vendor restored successfully"
+	utils.RespondWithOK(ctx, restoredMessage, vendor)
+}
+
+// AssignVendorOwner godoc
+// @Summary Assign a vendor owner
+// @Description Hand a vendor listing to a user so they can manage it (admin only). Contributors are promoted to vendor owners.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param owner body models.AssignVendorOwnerRequest true "New owner"
+// @Success 200 {object} CreatedResponse "Vendor owner assigned successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Admin role required"
+// @Failure 404 {object} NotFoundResponse "Vendor or user not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/vendors/{id}/owner [put]
+func (h *VendorHandler) AssignVendorOwner(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.AssignVendorOwnerRequest
+	if !utils.BindJSON(ctx, &request, "Failed to assign vendor owner") {
+		return
+	}
+
+	if err := h.repository.AssignVendorOwner(ctx, parsedUUID, request.UserID); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrVendorNotFound):
+			utils.RespondWithNotFound(ctx, err.Error(), "Vendor does not exist")
+		case errors.Is(err, postgres.ErrUserNotFound):
+			utils.RespondWithNotFound(ctx, err.Error(), "User does not exist")
+		default:
+			utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to assign vendor owner")
+		}
+		return
+	}
+
+	vendor, err := h.repository.GetVendorByID(ctx, parsedUUID)
+	if err != nil {
+		userMessage := "Failed to assign vendor owner"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	vendor.ResolveURLs(h.storage.URL)
 
+	assignedMessage := "Vendor owner assigned successfully"
+	utils.RespondWithOK(ctx, assignedMessage, vendor)
 }
 
+// parseVendorListParams reads the pagination, filter and sort query parameters of a vendor listing
+func parseVendorListParams(ctx *gin.Context) (*models.VendorListParams, error) {
+	pagination, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	params := &models.VendorListParams{
+		Filter: models.VendorFilter{
+			City:   strings.TrimSpace(ctx.Query("city")),
+			Region: strings.TrimSpace(ctx.Query("region")),
+		},
+		Sort:      models.VendorSort(ctx.DefaultQuery("sort", string(models.VendorSortNewest))),
+		Page:      pagination.Page,
+		PageSize:  pagination.PageSize,
+		UseCursor: pagination.UseCursor,
+		Cursor:    pagination.Cursor,
+	}
 
+	if params.Filter.IsVerified, err = utils.ParseOptionalBool(ctx, "is_verified"); err != nil {
+		return nil, err
+	}
+	if params.Filter.HasImage, err = utils.ParseOptionalBool(ctx, "has_image"); err != nil {
+		return nil, err
+	}
+	if params.Filter.OpenNow, err = utils.ParseOptionalBool(ctx, "open_now"); err != nil {
+		return nil, err
+	}
+	if params.Filter.MinRating, err = utils.ParseOptionalFloat64(ctx, "min_rating", 0, 5); err != nil {
+		return nil, err
+	}
+	if params.Latitude, err = utils.ParseOptionalFloat64(ctx, "lat", -90, 90); err != nil {
+		return nil, err
+	}
+	if params.Longitude, err = utils.ParseOptionalFloat64(ctx, "lng", -180, 180); err != nil {
+		return nil, err
+	}
+	if (params.Latitude == nil) != (params.Longitude == nil) {
+		return nil, errors.New("'lat' and 'lng' must be sent together")
+	}
 
+	return params, nil
+}
 
+// respondWithListError maps listing errors caused by bad query parameters to a 400
+func respondWithListError(ctx *gin.Context, err error, userMessage string) {
+	if errors.Is(err, postgres.ErrUnknownSort) || errors.Is(err, postgres.ErrDistanceSortNeedsLocation) ||
+		errors.Is(err, postgres.ErrCursorSortMismatch) || errors.Is(err, utils.ErrInvalidCursor) {
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+	utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+}
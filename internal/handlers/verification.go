@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aglili/waakye-directory/internal/middleware"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationHandler struct {
+	repository postgres.VerificationRepository
+	storage    storage.Storage
+}
+
+func NewVerificationHandler(repository postgres.VerificationRepository, store storage.Storage) *VerificationHandler {
+	return &VerificationHandler{
+		repository: repository,
+		storage:    store,
+	}
+}
+
+// SubmitVerification godoc
+// @Summary Submit verification evidence
+// @Description Attach photos uploaded through /api/v1/uploads, given by upload_id, as evidence that a vendor is real. Pending or rejected vendors move to under_review when the submitter created or owns the vendor, or is a moderator; anyone else only adds evidence.
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param evidence body models.SubmitVerificationRequest true "Evidence"
+// @Success 201 {object} CreatedResponse "Verification evidence submitted successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/vendors/{id}/verification [post]
+func (h *VerificationHandler) SubmitVerification(ctx *gin.Context) {
+	userID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.SubmitVerificationRequest
+	if !utils.BindJSON(ctx, &request, "Failed to submit verification evidence") {
+		return
+	}
+
+	moderator := middleware.HasPermission(ctx, middleware.PermVendorVerify)
+	if err := h.repository.SubmitEvidence(ctx, parsedUUID, userID, moderator, &request); err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to submit verification evidence"
+		if errors.Is(err, postgres.ErrInvalidEvidence) {
+			utils.RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, []utils.FieldError{{
+				Field:   "evidence",
+				Rule:    "upload",
+				Message: "must be images you uploaded through /api/v1/uploads",
+			}})
+			return
+		}
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	verification, err := h.repository.GetVerification(ctx, parsedUUID)
+	if err != nil {
+		userMessage := "Failed to submit verification evidence"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	createdMessage := "Verification evidence submitted successfully"
+	utils.RespondWithCreated(ctx, createdMessage, gin.H{
+		"vendor_id": verification.VendorID,
+		"status":    verification.Status,
+	})
+}
+
+// ListVerificationQueue godoc
+// @Summary List the verification queue
+// @Description List vendors in a verification state, oldest first (admins and moderators only)
+// @Tags verification
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Verification status (pending, under_review, verified, rejected)" default(under_review)
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} PaginatedResponse "Verification queue retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/verifications [get]
+func (h *VerificationHandler) ListVerificationQueue(ctx *gin.Context) {
+	params, err := utils.GetPaginationParams(ctx)
+	if err != nil {
+		userMessage := "Failed to list verification queue"
+		utils.RespondWithBadRequest(ctx, err.Error(), userMessage)
+		return
+	}
+
+	status := models.VerificationStatus(ctx.DefaultQuery("status", string(models.VerificationUnderReview)))
+	if !status.IsValid() {
+		userMessage := "Invalid verification status"
+		utils.RespondWithBadRequest(ctx, fmt.Sprintf("unknown status %q", status), userMessage)
+		return
+	}
+
+	queue, err := h.repository.ListVerificationQueue(ctx, status, params.Page, params.PageSize)
+	if err != nil {
+		userMessage := "Failed to list verification queue"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	totalItems, err := h.repository.CountVerificationQueue(ctx, status)
+	if err != nil {
+		userMessage := "Failed to list verification queue"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	getMessage := "Verification queue retrieved successfully"
+	utils.SendPaginatedResponse(ctx, queue, params.Page, params.PageSize, totalItems, getMessage)
+}
+
+// GetVerification godoc
+// @Summary Get a vendor's verification record
+// @Description Get the verification state, evidence and history of a vendor (admins and moderators only)
+// @Tags verification
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Success 200 {object} CreatedResponse "Verification retrieved successfully"
+// @Failure 400 {object} BadRequestResponse "Invalid UUID format"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/verifications/{id} [get]
+func (h *VerificationHandler) GetVerification(ctx *gin.Context) {
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	verification, err := h.repository.GetVerification(ctx, parsedUUID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVendorNotFound) {
+			userMessage := "Vendor does not exist"
+			utils.RespondWithNotFound(ctx, err.Error(), userMessage)
+			return
+		}
+		userMessage := "Failed to get verification"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	verification.ResolveURLs(h.storage.URL)
+
+	getMessage := "Verification retrieved successfully"
+	utils.RespondWithOK(ctx, getMessage, verification)
+}
+
+// ApproveVerification godoc
+// @Summary Approve a vendor
+// @Description Mark a vendor as verified (admins and moderators only)
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param decision body models.VerificationDecisionRequest false "Reviewer notes"
+// @Success 200 {object} CreatedResponse "Vendor verified successfully"
+// @Failure 400 {object} BadRequestResponse "Bad request or invalid state change"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/verifications/{id}/approve [post]
+func (h *VerificationHandler) ApproveVerification(ctx *gin.Context) {
+	h.decide(ctx, models.VerificationVerified, "Vendor verified successfully")
+}
+
+// RejectVerification godoc
+// @Summary Reject a vendor
+// @Description Reject a vendor's verification or revoke an existing one (admins and moderators only). Notes are required so the vendor knows why.
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vendor ID"
+// @Param decision body models.VerificationDecisionRequest true "Reviewer notes"
+// @Success 200 {object} CreatedResponse "Vendor verification rejected"
+// @Failure 400 {object} BadRequestResponse "Bad request or invalid state change"
+// @Failure 401 {object} BadRequestResponse "Authentication required"
+// @Failure 403 {object} BadRequestResponse "Moderator role required"
+// @Failure 404 {object} NotFoundResponse "Vendor not found"
+// @Failure 422 {object} utils.ValidationErrorResponse "Validation failed"
+// @Failure 500 {object} InternalServerErrorResponse "Internal server error"
+// @Router /api/v1/admin/verifications/{id}/reject [post]
+func (h *VerificationHandler) RejectVerification(ctx *gin.Context) {
+	h.decide(ctx, models.VerificationRejected, "Vendor verification rejected")
+}
+
+func (h *VerificationHandler) decide(ctx *gin.Context, to models.VerificationStatus, successMessage string) {
+	actorID, ok := middleware.CurrentUserID(ctx)
+	if !ok {
+		utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+		return
+	}
+
+	parsedUUID, ok := utils.ParseUUID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var request models.VerificationDecisionRequest
+	if ctx.Request.ContentLength != 0 {
+		if !utils.BindJSON(ctx, &request, "Failed to update verification") {
+			return
+		}
+	}
+
+	if to == models.VerificationRejected && strings.TrimSpace(request.Notes) == "" {
+		userMessage := "Notes are required when rejecting a vendor"
+		utils.RespondWithUnprocessableEntity(ctx, "rejection without notes", userMessage, []utils.FieldError{{
+			Field:   "notes",
+			Rule:    "required",
+			Message: "is required when rejecting a vendor",
+		}})
+		return
+	}
+
+	if err := h.repository.TransitionVerification(ctx, parsedUUID, actorID, to, request.Notes); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrVendorNotFound):
+			utils.RespondWithNotFound(ctx, err.Error(), "Vendor does not exist")
+		case errors.Is(err, postgres.ErrInvalidVerificationTransition):
+			utils.RespondWithBadRequest(ctx, err.Error(), fmt.Sprintf("Vendor cannot be moved to %s from its current state", to))
+		default:
+			utils.RespondWithInternalServerError(ctx, err.Error(), "Failed to update verification")
+		}
+		return
+	}
+
+	verification, err := h.repository.GetVerification(ctx, parsedUUID)
+	if err != nil {
+		userMessage := "Failed to update verification"
+		utils.RespondWithInternalServerError(ctx, err.Error(), userMessage)
+		return
+	}
+
+	verification.ResolveURLs(h.storage.URL)
+
+	utils.RespondWithOK(ctx, successMessage, verification)
+}
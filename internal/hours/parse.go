@@ -0,0 +1,199 @@
+package hours
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnparseable is returned when free text hours yield no opening at all
+var ErrUnparseable = errors.New("could not find any opening hours in text")
+
+var (
+	dayNames = map[string]time.Weekday{
+		"sun": time.Sunday, "sunday": time.Sunday, "sundays": time.Sunday,
+		"mon": time.Monday, "monday": time.Monday, "mondays": time.Monday,
+		"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday, "tuesdays": time.Tuesday,
+		"wed": time.Wednesday, "weds": time.Wednesday, "wednesday": time.Wednesday, "wednesdays": time.Wednesday,
+		"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday, "thursdays": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday, "fridays": time.Friday,
+		"sat": time.Saturday, "saturday": time.Saturday, "saturdays": time.Saturday,
+	}
+
+	dayWord      = `(sun|mon|tue|wed|thu|fri|sat)[a-z]*`
+	dayRangeExpr = regexp.MustCompile(dayWord + `\s*(?:-|–|to|through|thru|till|until)\s*` + dayWord)
+	dayExpr      = regexp.MustCompile(`\b` + dayWord + `\b`)
+
+	clockWord     = `(\d{1,2})(?:[:.h](\d{2}))?\s*(am|pm|a\.m\.?|p\.m\.?)?`
+	timeRangeExpr = regexp.MustCompile(clockWord + `\s*(?:-|–|to|till|until)\s*` + clockWord)
+
+	segmentSeparators = regexp.MustCompile(`[;,\n|/]+`)
+)
+
+var allDays = []time.Weekday{
+	time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday,
+}
+
+// Parse makes a best-effort reading of free text hours such as "Mon-Sat 6am - 11am, Sun closed"
+// or "Daily 5:30 to 12 noon". Segments are split on commas, semicolons and new lines; a time range
+// without days applies to the days named just before it, or to every day if none were.
+// Anything it cannot make sense of is skipped.
+func Parse(text string) ([]Span, error) {
+	normalised := strings.ToLower(text)
+	normalised = strings.NewReplacer(
+		"12 noon", "12:00pm", "noon", "12:00pm", "midday", "12:00pm",
+		"12 midnight", "12:00am", "midnight", "12:00am",
+		"o'clock", "", "hrs", "", "—", "-",
+	).Replace(normalised)
+
+	var spans []Span
+	var pendingDays []time.Weekday
+
+	for _, segment := range segmentSeparators.Split(normalised, -1) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+
+		days := parseDays(segment)
+		if len(days) > 0 {
+			pendingDays = days
+		}
+
+		if strings.Contains(segment, "closed") {
+			pendingDays = nil
+			continue
+		}
+
+		match := timeRangeExpr.FindStringSubmatch(segment)
+		if match == nil {
+			continue
+		}
+
+		opens, closes, ok := parseTimeRange(match)
+		if !ok {
+			continue
+		}
+
+		appliesTo := pendingDays
+		if len(appliesTo) == 0 {
+			appliesTo = allDays
+		}
+		for _, day := range appliesTo {
+			spans = append(spans, Span{Weekday: day, Opens: opens, Closes: closes})
+		}
+	}
+
+	if len(spans) == 0 {
+		return nil, ErrUnparseable
+	}
+	return spans, nil
+}
+
+// parseDays reads day ranges ("mon-fri"), lists of days and words like "daily" or "weekdays"
+func parseDays(segment string) []time.Weekday {
+	switch {
+	case strings.Contains(segment, "daily"), strings.Contains(segment, "everyday"),
+		strings.Contains(segment, "every day"), strings.Contains(segment, "all week"):
+		return allDays
+	case strings.Contains(segment, "weekdays"):
+		return []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	case strings.Contains(segment, "weekends"):
+		return []time.Weekday{time.Saturday, time.Sunday}
+	}
+
+	seen := map[time.Weekday]bool{}
+	var days []time.Weekday
+	add := func(day time.Weekday) {
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	for _, match := range dayRangeExpr.FindAllStringSubmatch(segment, -1) {
+		from, to := dayNames[match[1]], dayNames[match[2]]
+		for day := from; ; day = (day + 1) % 7 {
+			add(day)
+			if day == to {
+				break
+			}
+		}
+	}
+	segment = dayRangeExpr.ReplaceAllString(segment, " ")
+
+	for _, match := range dayExpr.FindAllStringSubmatch(segment, -1) {
+		add(dayNames[match[1]])
+	}
+
+	return days
+}
+
+// parseTimeRange turns a timeRangeExpr match into clocks. A start without am/pm borrows the
+// end's when that keeps the range in order, so "6 - 11am" reads as 06:00-11:00.
+func parseTimeRange(match []string) (Clock, Clock, bool) {
+	startMeridiem, endMeridiem := meridiem(match[3]), meridiem(match[6])
+	if startMeridiem == "" && endMeridiem != "" {
+		borrowed, ok := toClock(match[1], match[2], endMeridiem)
+		end, endOK := toClock(match[4], match[5], endMeridiem)
+		if ok && endOK && borrowed < end {
+			startMeridiem = endMeridiem
+		}
+	}
+
+	opens, ok := toClock(match[1], match[2], startMeridiem)
+	if !ok {
+		return 0, 0, false
+	}
+	closes, ok := toClock(match[4], match[5], endMeridiem)
+	if !ok {
+		return 0, 0, false
+	}
+	return opens, closes, true
+}
+
+func meridiem(value string) string {
+	switch {
+	case strings.HasPrefix(value, "a"):
+		return "am"
+	case strings.HasPrefix(value, "p"):
+		return "pm"
+	}
+	return ""
+}
+
+// toClock converts an hour, optional minutes and optional am/pm to a Clock
+func toClock(hourText, minuteText, meridiem string) (Clock, bool) {
+	hour, err := strconv.Atoi(hourText)
+	if err != nil {
+		return 0, false
+	}
+	minute := 0
+	if minuteText != "" {
+		if minute, err = strconv.Atoi(minuteText); err != nil || minute > 59 {
+			return 0, false
+		}
+	}
+
+	switch meridiem {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return 0, false
+		}
+		hour %= 12
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return 0, false
+		}
+		hour = hour%12 + 12
+	default:
+		if hour > 24 {
+			return 0, false
+		}
+		hour %= 24
+	}
+
+	return Clock(hour*60 + minute), true
+}
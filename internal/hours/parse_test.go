@@ -0,0 +1,127 @@
+package hours
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mustClock reads an HH:MM clock for test tables
+func mustClock(value string) Clock {
+	clock, err := ParseClock(value)
+	if err != nil {
+		panic(err)
+	}
+	return clock
+}
+
+// spansOn builds the same opening on each of days
+func spansOn(opens, closes string, days ...time.Weekday) []Span {
+	spans := make([]Span, 0, len(days))
+	for _, day := range days {
+		spans = append(spans, Span{Weekday: day, Opens: mustClock(opens), Closes: mustClock(closes)})
+	}
+	return spans
+}
+
+func TestParse(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	tests := []struct {
+		name string
+		text string
+		want []Span
+	}{
+		{
+			name: "day range with a closed day",
+			text: "Mon-Sat 6am - 11am, Sun closed",
+			want: spansOn("06:00", "11:00", time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday),
+		},
+		{
+			name: "daily until noon",
+			text: "Daily 5:30 to 12 noon",
+			want: spansOn("05:30", "12:00", allDays...),
+		},
+		{
+			name: "until midnight",
+			text: "Fri 6pm - 12 midnight",
+			want: spansOn("18:00", "00:00", time.Friday),
+		},
+		{
+			name: "overnight in 24 hour time",
+			text: "Daily 22:00 - 02:00",
+			want: spansOn("22:00", "02:00", allDays...),
+		},
+		{
+			name: "open all day",
+			text: "Sundays 00:00 - 00:00",
+			want: spansOn("00:00", "00:00", time.Sunday),
+		},
+		{
+			name: "start borrows the end's meridiem",
+			text: "Weekdays 6 - 11am",
+			want: spansOn("06:00", "11:00", weekdays...),
+		},
+		{
+			name: "start keeps its own hour when borrowing would reverse the range",
+			text: "Sat 10 - 2pm",
+			want: spansOn("10:00", "14:00", time.Saturday),
+		},
+		{
+			name: "days carry over to the next segment",
+			text: "Mon & Wed\n7am-10am",
+			want: spansOn("07:00", "10:00", time.Monday, time.Wednesday),
+		},
+		{
+			name: "day range wrapping past Saturday",
+			text: "Fri to Mon 7.30am till 9pm",
+			want: spansOn("07:30", "21:00", time.Friday, time.Saturday, time.Sunday, time.Monday),
+		},
+		{
+			name: "several segments",
+			text: "Weekdays 6am-10am; Weekends 8am - 12 noon",
+			want: append(spansOn("06:00", "10:00", weekdays...), spansOn("08:00", "12:00", time.Saturday, time.Sunday)...),
+		},
+		{
+			name: "no days means every day",
+			text: "6am - 10am",
+			want: spansOn("06:00", "10:00", allDays...),
+		},
+		{
+			name: "unreadable times are skipped",
+			text: "Mon 13pm - 2pm, Tue 6am - 9am",
+			want: spansOn("06:00", "09:00", time.Tuesday),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%v\nwant\n%v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnparseable(t *testing.T) {
+	tests := []string{
+		"",
+		"12 midnight",
+		"Sun closed",
+		"call before you come",
+		"Mon 25:00 - 26:00",
+	}
+
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			if spans, err := Parse(text); !errors.Is(err, ErrUnparseable) {
+				t.Errorf("Parse(%q) = %v, %v, want ErrUnparseable", text, spans, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package hours models a vendor's weekly opening hours and the closures or special hours that
+// override them. All times are wall clock times in Africa/Accra.
+package hours
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	// Containers often ship without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// Accra is the time zone every schedule is expressed in
+var Accra = mustLoadLocation("Africa/Accra")
+
+// DateLayout is how calendar dates, such as the days an override covers, are written
+const DateLayout = "2006-01-02"
+
+// lookAheadDays bounds the search for the next opening, long enough to see past a holiday closure
+const lookAheadDays = 31
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("hours: load %s: %v", name, err))
+	}
+	return location
+}
+
+// ErrInvalidClock is returned when a time of day is not in HH:MM form
+var ErrInvalidClock = errors.New("time of day must be HH:MM between 00:00 and 23:59")
+
+// Clock is a time of day in minutes since midnight
+type Clock int
+
+// ParseClock reads a 24 hour HH:MM time of day
+func ParseClock(value string) (Clock, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, ErrInvalidClock
+	}
+	return Clock(parsed.Hour()*60 + parsed.Minute()), nil
+}
+
+// String formats the clock as HH:MM
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", int(c)/60, int(c)%60)
+}
+
+// Span is a single opening on a weekday. A span whose close is not after its open runs past
+// midnight into the next day, so 18:00-02:00 is an overnight span and 00:00-00:00 is open all day.
+type Span struct {
+	Weekday time.Weekday
+	Opens   Clock
+	Closes  Clock
+}
+
+// Overnight reports whether the span closes on the following day
+func (s Span) Overnight() bool {
+	return s.Closes <= s.Opens
+}
+
+// Override replaces the weekly spans on every day from StartsOn to EndsOn inclusive, either
+// closing the vendor for the whole day or opening it between Opens and Closes.
+// StartsOn and EndsOn are dates at midnight in Accra.
+type Override struct {
+	StartsOn time.Time
+	EndsOn   time.Time
+	Closed   bool
+	Opens    Clock
+	Closes   Clock
+}
+
+// covers reports whether the override applies to the given date
+func (o Override) covers(day time.Time) bool {
+	return !day.Before(o.StartsOn) && !day.After(o.EndsOn)
+}
+
+// Schedule is a vendor's weekly hours together with any overrides. Overrides are checked in
+// order and the first one covering a day wins, so callers list the most recent first.
+type Schedule struct {
+	Weekly    []Span
+	Overrides []Override
+}
+
+// IsEmpty reports whether the schedule has no structured hours at all
+func (s Schedule) IsEmpty() bool {
+	return len(s.Weekly) == 0 && len(s.Overrides) == 0
+}
+
+// interval is a concrete opening between two instants
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// Date truncates t to midnight of its day in Accra
+func Date(t time.Time) time.Time {
+	local := t.In(Accra)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Accra)
+}
+
+// openingsOn lists the intervals that start on the given date
+func (s Schedule) openingsOn(day time.Time) []interval {
+	at := func(c Clock) time.Time { return day.Add(time.Duration(c) * time.Minute) }
+	span := func(opens, closes Clock) interval {
+		end := at(closes)
+		if closes <= opens {
+			end = end.AddDate(0, 0, 1)
+		}
+		return interval{start: at(opens), end: end}
+	}
+
+	for _, override := range s.Overrides {
+		if override.covers(day) {
+			if override.Closed {
+				return nil
+			}
+			return []interval{span(override.Opens, override.Closes)}
+		}
+	}
+
+	var openings []interval
+	for _, weekly := range s.Weekly {
+		if weekly.Weekday == day.Weekday() {
+			openings = append(openings, span(weekly.Opens, weekly.Closes))
+		}
+	}
+	return openings
+}
+
+// IsOpenAt reports whether the vendor is open at t, including spans carried over from the night before
+func (s Schedule) IsOpenAt(t time.Time) bool {
+	today := Date(t)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		for _, opening := range s.openingsOn(day) {
+			if !t.Before(opening.start) && t.Before(opening.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextOpenAt returns when the vendor next opens after t, or nil when it is open at t or has no
+// opening within the look ahead window
+func (s Schedule) NextOpenAt(t time.Time) *time.Time {
+	if s.IsOpenAt(t) {
+		return nil
+	}
+
+	today := Date(t)
+	for offset := 0; offset <= lookAheadDays; offset++ {
+		var next *time.Time
+		for _, opening := range s.openingsOn(today.AddDate(0, 0, offset)) {
+			if opening.start.After(t) && (next == nil || opening.start.Before(*next)) {
+				start := opening.start
+				next = &start
+			}
+		}
+		if next != nil {
+			return next
+		}
+	}
+	return nil
+}
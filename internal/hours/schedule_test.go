@@ -0,0 +1,130 @@
+package hours
+
+import (
+	"testing"
+	"time"
+)
+
+// In May 2024 the 6th is a Monday, the 10th a Friday and the 12th a Sunday
+
+// at is the given day of May 2024 at HH:MM in Accra
+func at(day int, clock string) time.Time {
+	return date(day).Add(time.Duration(mustClock(clock)) * time.Minute)
+}
+
+// date is midnight on the given day of May 2024 in Accra
+func date(day int) time.Time {
+	return time.Date(2024, time.May, day, 0, 0, 0, 0, Accra)
+}
+
+func TestIsOpenAt(t *testing.T) {
+	mornings := Schedule{Weekly: spansOn("06:00", "11:00", time.Monday)}
+	overnight := Schedule{Weekly: spansOn("22:00", "02:00", time.Friday)}
+	allDay := Schedule{Weekly: spansOn("00:00", "00:00", time.Sunday)}
+	closedMonday := Schedule{
+		Weekly:    mornings.Weekly,
+		Overrides: []Override{{StartsOn: date(6), EndsOn: date(6), Closed: true}},
+	}
+	specialMonday := Schedule{
+		Weekly:    mornings.Weekly,
+		Overrides: []Override{{StartsOn: date(6), EndsOn: date(6), Opens: mustClock("12:00"), Closes: mustClock("14:00")}},
+	}
+	firstOverrideWins := Schedule{
+		Weekly: mornings.Weekly,
+		Overrides: []Override{
+			{StartsOn: date(6), EndsOn: date(6), Closed: true},
+			{StartsOn: date(1), EndsOn: date(31), Opens: mustClock("06:00"), Closes: mustClock("11:00")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     bool
+	}{
+		{"before opening", mornings, at(6, "05:59"), false},
+		{"at opening", mornings, at(6, "06:00"), true},
+		{"before closing", mornings, at(6, "10:59"), true},
+		{"at closing", mornings, at(6, "11:00"), false},
+		{"another weekday", mornings, at(7, "07:00"), false},
+		{"same instant in another zone", mornings, time.Date(2024, time.May, 6, 8, 0, 0, 0, time.FixedZone("WAT", 60*60)), true},
+
+		{"overnight before opening", overnight, at(10, "21:59"), false},
+		{"overnight before midnight", overnight, at(10, "23:00"), true},
+		{"overnight carried into the next day", overnight, at(11, "01:30"), true},
+		{"overnight at closing", overnight, at(11, "02:00"), false},
+		{"overnight the night before", overnight, at(9, "23:00"), false},
+
+		{"all day at midnight", allDay, at(12, "00:00"), true},
+		{"all day before midnight", allDay, at(12, "23:59"), true},
+		{"all day the next midnight", allDay, at(13, "00:00"), false},
+		{"all day the day before", allDay, at(11, "23:59"), false},
+
+		{"closed by an override", closedMonday, at(6, "07:00"), false},
+		{"open the week after an override", closedMonday, at(13, "07:00"), true},
+		{"special hours replace the weekly ones", specialMonday, at(6, "07:00"), false},
+		{"open during special hours", specialMonday, at(6, "13:00"), true},
+		{"first covering override wins", firstOverrideWins, at(6, "07:00"), false},
+		{"later override applies on other days", firstOverrideWins, at(8, "07:00"), true},
+
+		{"empty schedule", Schedule{}, at(6, "07:00"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.IsOpenAt(tt.at); got != tt.want {
+				t.Errorf("IsOpenAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOpenAt(t *testing.T) {
+	mornings := Schedule{Weekly: spansOn("06:00", "11:00", time.Monday)}
+	twoSittings := Schedule{Weekly: append(spansOn("17:00", "21:00", time.Monday), spansOn("06:00", "11:00", time.Monday)...)}
+	overnight := Schedule{Weekly: spansOn("22:00", "02:00", time.Friday)}
+	closedMonday := Schedule{
+		Weekly:    mornings.Weekly,
+		Overrides: []Override{{StartsOn: date(6), EndsOn: date(6), Closed: true}},
+	}
+	closedForWeeks := Schedule{
+		Weekly:    mornings.Weekly,
+		Overrides: []Override{{StartsOn: date(1), EndsOn: date(1).AddDate(0, 0, 40), Closed: true}},
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     *time.Time
+	}{
+		{"later the same day", mornings, at(6, "05:00"), ptr(at(6, "06:00"))},
+		{"next week", mornings, at(6, "12:00"), ptr(at(13, "06:00"))},
+		{"earliest remaining sitting", twoSittings, at(6, "12:00"), ptr(at(6, "17:00"))},
+		{"overnight opening", overnight, at(10, "21:00"), ptr(at(10, "22:00"))},
+		{"after an overnight closing", overnight, at(11, "02:00"), ptr(at(17, "22:00"))},
+		{"skips a closure", closedMonday, at(6, "05:00"), ptr(at(13, "06:00"))},
+		{"open now", mornings, at(6, "07:00"), nil},
+		{"open on an overnight carry over", overnight, at(11, "01:00"), nil},
+		{"closed past the look ahead", closedForWeeks, at(6, "05:00"), nil},
+		{"empty schedule", Schedule{}, at(6, "05:00"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.NextOpenAt(tt.at)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("NextOpenAt(%s) = %v, want %v", tt.at, got, tt.want)
+			case !got.Equal(*tt.want):
+				t.Errorf("NextOpenAt(%s) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
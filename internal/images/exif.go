@@ -0,0 +1,314 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// TIFF tags read or scrubbed from an image's EXIF block
+const (
+	orientationTag = 0x0112
+	gpsIFDTag      = 0x8825
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// HEIC files are searched for the EXIF header heicWindow bytes at a time, and the TIFF block
+// behind it is read up to maxHEICExif bytes
+const (
+	heicWindow  = 64 << 10
+	maxHEICExif = 1 << 20
+)
+
+// patch replaces len(data) bytes of a file at offset
+type patch struct {
+	offset int64
+	data   []byte
+}
+
+// Scrubbed is an accepted upload with its GPS location removed. The upload itself is left alone:
+// only its EXIF blocks are read into memory, and reads return them scrubbed, so the file keeps
+// its size and structure and can be streamed to storage.
+type Scrubbed struct {
+	file        io.ReaderAt
+	size        int64
+	contentType string
+	// orientation is the EXIF orientation (1 to 8), or 1 when there is none
+	orientation int
+	patches     []patch
+}
+
+// Scrub finds the GPS location in every EXIF block of an accepted upload of size bytes
+func Scrub(file io.ReaderAt, size int64, contentType string) *Scrubbed {
+	s := &Scrubbed{file: file, size: size, contentType: contentType, orientation: 1}
+	switch contentType {
+	case "image/jpeg":
+		s.scrubJPEG()
+	case "image/png":
+		s.scrubPNG()
+	case "image/webp":
+		s.scrubWebP()
+	default:
+		s.scrubHEIC()
+	}
+	return s
+}
+
+// Size is the size of the file in bytes
+func (s *Scrubbed) Size() int64 {
+	return s.size
+}
+
+// Open reads the scrubbed file from the start
+func (s *Scrubbed) Open() io.Reader {
+	return io.NewSectionReader(s, 0, s.size)
+}
+
+// ReadAt reads the scrubbed file. Later patches win where they overlap earlier ones, since they
+// were read through them.
+func (s *Scrubbed) ReadAt(p []byte, off int64) (int, error) {
+	n, err := s.file.ReadAt(p, off)
+	for _, patch := range s.patches {
+		start := max(patch.offset, off)
+		end := min(patch.offset+int64(len(patch.data)), off+int64(n))
+		if start < end {
+			copy(p[start-off:end-off], patch.data[start-patch.offset:end-patch.offset])
+		}
+	}
+	return n, err
+}
+
+// readUpTo returns at most n bytes of the scrubbed file from offset
+func (s *Scrubbed) readUpTo(offset, n int64) []byte {
+	if offset < 0 || offset >= s.size {
+		return nil
+	}
+	buf := make([]byte, min(n, s.size-offset))
+	read, _ := s.ReadAt(buf, offset)
+	return buf[:read]
+}
+
+// read returns the n bytes of the scrubbed file at offset, or false when the file ends first
+func (s *Scrubbed) read(offset, n int64) ([]byte, bool) {
+	if offset+n > s.size {
+		return nil, false
+	}
+	buf := s.readUpTo(offset, n)
+	return buf, int64(len(buf)) == n
+}
+
+// scrubTIFFAt scrubs the TIFF block read at offset and patches the file with the result
+func (s *Scrubbed) scrubTIFFAt(offset int64, tiff []byte) {
+	if orientation, ok := scrubTIFF(tiff); ok {
+		s.orientation = orientation
+		s.patches = append(s.patches, patch{offset: offset, data: tiff})
+	}
+}
+
+// scrubJPEG walks the segments before the image data and scrubs the EXIF APP1 ones
+func (s *Scrubbed) scrubJPEG() {
+	for i := int64(2); ; {
+		header, ok := s.read(i, 4)
+		if !ok || header[0] != 0xFF {
+			return
+		}
+		marker := header[1]
+		switch {
+		case marker == 0xFF:
+			i++
+			continue
+		case marker == 0xD8 || marker >= 0xD0 && marker <= 0xD7:
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			return
+		}
+
+		// The length counts its own two bytes, so anything shorter means the file is corrupt
+		length := int64(binary.BigEndian.Uint16(header[2:]))
+		if length < 2 || i+2+length > s.size {
+			return
+		}
+		if marker == 0xE1 {
+			segment, _ := s.read(i+4, length-2)
+			if bytes.HasPrefix(segment, exifHeader) {
+				s.scrubTIFFAt(i+4+int64(len(exifHeader)), segment[len(exifHeader):])
+			}
+		}
+		i += 2 + length
+	}
+}
+
+// scrubPNG scrubs eXIf chunks and fixes up their checksums
+func (s *Scrubbed) scrubPNG() {
+	for i := int64(8); ; {
+		header, ok := s.read(i, 8)
+		if !ok {
+			return
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		end := i + 12 + length
+		if end > s.size {
+			return
+		}
+		kind := string(header[4:])
+		if kind == "eXIf" {
+			// The checksum covers the chunk type and data
+			chunk, _ := s.read(i+4, 4+length)
+			if orientation, ok := scrubTIFF(chunk[4:]); ok {
+				s.orientation = orientation
+				chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk))
+				s.patches = append(s.patches, patch{offset: i + 4, data: chunk})
+			}
+		}
+		if kind == "IEND" {
+			return
+		}
+		i = end
+	}
+}
+
+// scrubWebP scrubs the EXIF chunk of a RIFF WebP file
+func (s *Scrubbed) scrubWebP() {
+	for i := int64(12); ; {
+		header, ok := s.read(i, 8)
+		if !ok {
+			return
+		}
+		length := int64(binary.LittleEndian.Uint32(header[4:]))
+		if i+8+length > s.size {
+			return
+		}
+		if string(header[:4]) == "EXIF" {
+			offset := i + 8
+			tiff, _ := s.read(offset, length)
+			if bytes.HasPrefix(tiff, exifHeader) {
+				tiff = tiff[len(exifHeader):]
+				offset += int64(len(exifHeader))
+			}
+			s.scrubTIFFAt(offset, tiff)
+		}
+		// Chunks are padded to an even length
+		i += 8 + length + length%2
+	}
+}
+
+// scrubHEIC scrubs the TIFF block behind every EXIF header, since HEIC keeps its EXIF as an item
+// somewhere in the file
+func (s *Scrubbed) scrubHEIC() {
+	for offset := int64(0); offset < s.size; {
+		window := s.readUpTo(offset, heicWindow)
+		i := bytes.Index(window, exifHeader)
+		if i < 0 {
+			// Keeping the tail of the window, in case the header straddles its end
+			offset += max(int64(len(window)-len(exifHeader)+1), 1)
+			continue
+		}
+		offset += int64(i + len(exifHeader))
+		s.scrubTIFFAt(offset, s.readUpTo(offset, maxHEICExif))
+	}
+}
+
+// scrubTIFF empties the GPS IFD of a TIFF structure and reads IFD0's orientation. ok is false
+// when tiff does not start with a TIFF header.
+func scrubTIFF(tiff []byte) (orientation int, ok bool) {
+	if len(tiff) < 8 {
+		return 1, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1, false
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1, false
+	}
+
+	orientation = 1
+	entries, ok := ifdEntries(tiff, order, order.Uint32(tiff[4:]))
+	if !ok {
+		return orientation, true
+	}
+	for _, entry := range entries {
+		switch order.Uint16(entry) {
+		case orientationTag:
+			if value := int(order.Uint16(entry[8:])); value >= 1 && value <= 8 {
+				orientation = value
+			}
+		case gpsIFDTag:
+			clearIFD(tiff, order, order.Uint32(entry[8:]))
+		}
+	}
+
+	return orientation, true
+}
+
+// clearIFD zeroes every entry of the IFD at offset and the values they point to, leaving an
+// empty IFD behind
+func clearIFD(tiff []byte, order binary.ByteOrder, offset uint32) {
+	entries, ok := ifdEntries(tiff, order, offset)
+	if !ok {
+		return
+	}
+
+	for _, entry := range entries {
+		size := uint64(typeSize(order.Uint16(entry[2:]))) * uint64(order.Uint32(entry[4:]))
+		if size <= 4 {
+			continue
+		}
+		valueOffset := uint64(order.Uint32(entry[8:]))
+		if valueOffset+size <= uint64(len(tiff)) {
+			clear(tiff[valueOffset : valueOffset+size])
+		}
+	}
+
+	// Zeroing the count, the entries and the next-IFD offset after them
+	end := uint64(offset) + 2 + 12*uint64(len(entries)) + 4
+	if end > uint64(len(tiff)) {
+		end = uint64(len(tiff))
+	}
+	clear(tiff[offset:end])
+}
+
+// ifdEntries slices the 12-byte entries of the IFD at offset
+func ifdEntries(tiff []byte, order binary.ByteOrder, offset uint32) ([][]byte, bool) {
+	start := uint64(offset)
+	if start == 0 || start+2 > uint64(len(tiff)) {
+		return nil, false
+	}
+
+	count := uint64(order.Uint16(tiff[start:]))
+	if start+2+12*count > uint64(len(tiff)) {
+		return nil, false
+	}
+
+	entries := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		at := start + 2 + 12*i
+		entries = append(entries, tiff[at:at+12])
+	}
+	return entries, true
+}
+
+// typeSize is the size in bytes of one value of a TIFF field type
+func typeSize(fieldType uint16) int {
+	switch fieldType {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	default:
+		return 0
+	}
+}
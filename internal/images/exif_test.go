@@ -0,0 +1,273 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+// Offsets inside the TIFF block built by exifTIFF
+const (
+	gpsIFDOffset   = 38
+	gpsValueOffset = 68
+	tiffSize       = 92
+)
+
+// exifTIFF builds a TIFF block whose IFD0 holds an orientation and a pointer to a GPS IFD with a
+// latitude reference and a latitude
+func exifTIFF(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, tiffSize)
+	if order == binary.ByteOrder(binary.LittleEndian) {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+
+	// IFD0: orientation, GPS IFD pointer
+	order.PutUint16(tiff[8:], 2)
+	putEntry(tiff[10:], order, orientationTag, 3, 1, 0)
+	order.PutUint16(tiff[18:], orientation)
+	putEntry(tiff[22:], order, gpsIFDTag, 4, 1, gpsIFDOffset)
+
+	// GPS IFD: GPSLatitudeRef "N", GPSLatitude as three rationals stored after the IFD
+	order.PutUint16(tiff[gpsIFDOffset:], 2)
+	putEntry(tiff[gpsIFDOffset+2:], order, 1, 2, 2, 0)
+	copy(tiff[gpsIFDOffset+10:], "N")
+	putEntry(tiff[gpsIFDOffset+14:], order, 2, 5, 3, gpsValueOffset)
+	for i, value := range []uint32{5, 1, 33, 1, 1234, 100} {
+		order.PutUint32(tiff[gpsValueOffset+4*i:], value)
+	}
+
+	return tiff
+}
+
+func putEntry(entry []byte, order binary.ByteOrder, tag, fieldType uint16, count, value uint32) {
+	order.PutUint16(entry, tag)
+	order.PutUint16(entry[2:], fieldType)
+	order.PutUint32(entry[4:], count)
+	order.PutUint32(entry[8:], value)
+}
+
+func jpegWithSegment(marker byte, payload []byte) []byte {
+	data := []byte{0xFF, 0xD8, 0xFF, marker}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(payload)+2))
+	data = append(data, payload...)
+	return append(data, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9)
+}
+
+func pngChunk(kind string, payload []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func pngWithChunks(chunks ...[]byte) []byte {
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = append(data, pngChunk("IHDR", make([]byte, 13))...)
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+	return append(data, pngChunk("IEND", nil)...)
+}
+
+func webpChunk(kind string, payload []byte) []byte {
+	chunk := append([]byte(kind), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func webpWithChunks(chunks ...[]byte) []byte {
+	var body []byte
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	data = append(data, "WEBP"...)
+	return append(data, body...)
+}
+
+// scrub runs Scrub over data and returns the file as it would be stored, with its orientation
+func scrub(t *testing.T, data []byte, contentType string) ([]byte, int) {
+	t.Helper()
+	scrubbed := Scrub(bytes.NewReader(data), int64(len(data)), contentType)
+	stored, err := io.ReadAll(scrubbed.Open())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored, scrubbed.orientation
+}
+
+func TestScrubGPS(t *testing.T) {
+	exif := func(order binary.ByteOrder, orientation uint16) []byte {
+		return append(append([]byte{}, exifHeader...), exifTIFF(order, orientation)...)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		// tiffAt is where the TIFF block starts in data
+		tiffAt      int
+		orientation int
+	}{
+		{
+			name:        "jpeg little endian",
+			contentType: "image/jpeg",
+			data:        jpegWithSegment(0xE1, exif(binary.LittleEndian, 6)),
+			tiffAt:      6 + len(exifHeader),
+			orientation: 6,
+		},
+		{
+			name:        "jpeg big endian after another segment",
+			contentType: "image/jpeg",
+			data: append(jpegWithSegment(0xE0, []byte("JFIF\x00"))[:11],
+				jpegWithSegment(0xE1, exif(binary.BigEndian, 8))[2:]...),
+			tiffAt:      11 + 4 + len(exifHeader),
+			orientation: 8,
+		},
+		{
+			name:        "png",
+			contentType: "image/png",
+			data:        pngWithChunks(pngChunk("eXIf", exifTIFF(binary.BigEndian, 3))),
+			tiffAt:      8 + 25 + 8,
+			orientation: 3,
+		},
+		{
+			name:        "webp with exif header",
+			contentType: "image/webp",
+			data:        webpWithChunks(webpChunk("VP8 ", []byte{1, 2, 3}), webpChunk("EXIF", exif(binary.LittleEndian, 5))),
+			tiffAt:      12 + 12 + 8 + len(exifHeader),
+			orientation: 5,
+		},
+		{
+			name:        "webp without exif header",
+			contentType: "image/webp",
+			data:        webpWithChunks(webpChunk("EXIF", exifTIFF(binary.BigEndian, 2))),
+			tiffAt:      12 + 8,
+			orientation: 2,
+		},
+		{
+			name:        "heic",
+			contentType: "image/heic",
+			data:        append(append([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00\x00\x00\x00\x04"), exif(binary.BigEndian, 7)...), "mdat"...),
+			tiffAt:      20 + len(exifHeader),
+			orientation: 7,
+		},
+		{
+			name:        "heic exif header across a search window",
+			contentType: "image/heic",
+			data:        append(make([]byte, heicWindow-3), exif(binary.LittleEndian, 4)...),
+			tiffAt:      heicWindow - 3 + len(exifHeader),
+			orientation: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]byte{}, tt.data...)
+			data, got := scrub(t, tt.data, tt.contentType)
+			if got != tt.orientation {
+				t.Errorf("orientation = %d, want %d", got, tt.orientation)
+			}
+			if !bytes.Equal(tt.data, original) {
+				t.Error("the upload itself was modified")
+			}
+			if len(data) != len(tt.data) {
+				t.Fatalf("size changed from %d to %d", len(tt.data), len(data))
+			}
+
+			tiff := data[tt.tiffAt : tt.tiffAt+tiffSize]
+			if !bytes.Equal(tiff[:gpsIFDOffset], tt.data[tt.tiffAt:tt.tiffAt+gpsIFDOffset]) {
+				t.Error("IFD0 was modified")
+			}
+			if gps := tiff[gpsIFDOffset:]; !bytes.Equal(gps, make([]byte, len(gps))) {
+				t.Errorf("GPS IFD and values were not zeroed: % x", gps)
+			}
+			if bytes.Contains(data, []byte{33, 0, 0, 0, 1, 0, 0, 0}) || bytes.Contains(data, []byte{0, 0, 0, 33, 0, 0, 0, 1}) {
+				t.Error("latitude survived scrubbing")
+			}
+		})
+	}
+}
+
+func TestScrubPNGFixesChecksum(t *testing.T) {
+	data, _ := scrub(t, pngWithChunks(pngChunk("eXIf", exifTIFF(binary.LittleEndian, 1))), "image/png")
+
+	chunk := data[8+25:]
+	length := int(binary.BigEndian.Uint32(chunk))
+	want := crc32.ChecksumIEEE(chunk[4 : 8+length])
+	if got := binary.BigEndian.Uint32(chunk[8+length:]); got != want {
+		t.Errorf("eXIf CRC = %08x, want %08x", got, want)
+	}
+}
+
+func TestScrubGPSMalformed(t *testing.T) {
+	tiff := exifTIFF(binary.LittleEndian, 6)
+	withTIFF := func(edit func(tiff []byte)) []byte {
+		edited := append([]byte{}, tiff...)
+		edit(edited)
+		return append(append([]byte{}, exifHeader...), edited...)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		orientation int
+	}{
+		{"empty jpeg", "image/jpeg", nil, 1},
+		{"jpeg start only", "image/jpeg", []byte{0xFF, 0xD8}, 1},
+		{"jpeg segment length 0", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00, 0xFF, 0xD9}, 1},
+		{"jpeg segment length 1", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xD9}, 1},
+		{"jpeg segment past the end", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 'E', 'x'}, 1},
+		{"jpeg segment cut short", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00}, 1},
+		{"jpeg fill bytes", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xFF, 0xFF, 0xFF}, 1},
+		{"jpeg exif too short for tiff", "image/jpeg", jpegWithSegment(0xE1, []byte("Exif\x00\x00II*")), 1},
+		{"png chunk past the end", "image/png", append([]byte("\x89PNG\r\n\x1a\n"), 0x7F, 0xFF, 0xFF, 0xFF, 'e', 'X', 'I', 'f', 0, 0, 0, 0), 1},
+		{"png eXIf without tiff", "image/png", pngWithChunks(pngChunk("eXIf", []byte("garbage"))), 1},
+		{"webp chunk past the end", "image/webp", append([]byte("RIFF\x00\x00\x00\x00WEBPEXIF"), 0xFF, 0xFF, 0xFF, 0x7F, 'I', 'I'), 1},
+		{"webp truncated header", "image/webp", []byte("RIFF\x00\x00\x00\x00WEBPEX"), 1},
+		{"heic exif header at the end", "image/heic", []byte("ftypheicExif\x00\x00"), 1},
+		{"heic exif header without tiff", "image/heic", []byte("Exif\x00\x00MM\x00\x2a"), 1},
+		{"ifd0 past the end", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint32(tiff[4:], 0xFFFFFFF0)
+		}), 1},
+		{"ifd0 count past the end", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint16(tiff[8:], 0xFFFF)
+		}), 1},
+		{"invalid orientation", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint16(tiff[18:], 9)
+		}), 1},
+		{"gps ifd past the end", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint32(tiff[30:], 0xFFFFFFFF)
+		}), 6},
+		{"gps ifd count past the end", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint16(tiff[gpsIFDOffset:], 0xFFFF)
+		}), 6},
+		{"gps value past the end", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint32(tiff[gpsIFDOffset+22:], 0xFFFFFFF0)
+		}), 6},
+		{"gps value count overflowing", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint32(tiff[gpsIFDOffset+18:], 0xFFFFFFFF)
+		}), 6},
+		{"gps ifd pointing at ifd0", "image/heic", withTIFF(func(tiff []byte) {
+			binary.LittleEndian.PutUint32(tiff[30:], 8)
+		}), 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := scrub(t, tt.data, tt.contentType); got != tt.orientation {
+				t.Errorf("orientation = %d, want %d", got, tt.orientation)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package images prepares uploaded photos for serving: it strips their GPS location and renders
+// the resized variants clients pick from on slow connections.
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxPixels caps the size of the images decoded for variants, so a small file can't claim
+// enormous dimensions and exhaust memory
+const maxPixels = 50_000_000
+
+// jpegQuality is what every variant is encoded at
+const jpegQuality = 82
+
+// ErrTooManyPixels is returned for images larger than maxPixels
+var ErrTooManyPixels = fmt.Errorf("images can be at most %d megapixels", maxPixels/1_000_000)
+
+// Spec describes one variant. Size bounds the longest side, or both sides of the centred
+// square when Crop is set.
+type Spec struct {
+	Name string
+	Size int
+	Crop bool
+}
+
+// Specs are the variants rendered for every upload, smallest first
+var Specs = []Spec{
+	{Name: models.ImageVariantThumb, Size: 200, Crop: true},
+	{Name: models.ImageVariantSmall, Size: 480},
+	{Name: models.ImageVariantMedium, Size: 960},
+	{Name: models.ImageVariantLarge, Size: 1600},
+}
+
+// Variant is one rendered, JPEG encoded variant
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Result is what Process learned about an image and the variants it rendered. Width and Height
+// are as displayed, after the EXIF orientation, and zero when the image could not be decoded.
+type Result struct {
+	Width    int
+	Height   int
+	Variants []Variant
+}
+
+// Process renders the variants of a scrubbed upload. Variants are only rendered for JPEG, PNG and
+// WebP; for HEIC, which can't be decoded here, none are returned. Resized sizes the image never
+// reaches are skipped rather than upscaled, so small images get fewer variants.
+func Process(upload *Scrubbed) (*Result, error) {
+	contentType := upload.contentType
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/webp" {
+		return &Result{}, nil
+	}
+
+	config, _, err := image.DecodeConfig(upload.Open())
+	if err != nil {
+		return nil, fmt.Errorf("read image header: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(upload.Open())
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	// Everything is rendered from one copy, no larger than the largest variant and turned the
+	// right way up, since the EXIF orientation does not survive re-encoding
+	largest := Specs[len(Specs)-1].Size
+	base := orient(fit(img, largest, largest), upload.orientation)
+
+	result := &Result{Width: config.Width, Height: config.Height}
+	if upload.orientation >= 5 {
+		result.Width, result.Height = config.Height, config.Width
+	}
+
+	result.Variants = make([]Variant, 0, len(Specs))
+	lastSize := 0
+	for _, spec := range Specs {
+		var rendered *image.RGBA
+		if spec.Crop {
+			rendered = thumbnail(base, spec.Size)
+		} else {
+			size := min(spec.Size, longestSide(base))
+			if size == lastSize {
+				continue
+			}
+			lastSize = size
+			rendered = fit(base, size, size)
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, rendered, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("encode %s variant: %w", spec.Name, err)
+		}
+
+		result.Variants = append(result.Variants, Variant{
+			Name:   spec.Name,
+			Width:  rendered.Bounds().Dx(),
+			Height: rendered.Bounds().Dy(),
+			Data:   buf.Bytes(),
+		})
+	}
+
+	return result, nil
+}
+
+// fit scales src down to fit within width x height, keeping its aspect ratio, onto a white
+// background so transparent PNGs survive JPEG encoding
+func fit(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > width || h > height {
+		scale := min(float64(width)/float64(w), float64(height)/float64(h))
+		w = max(1, int(float64(w)*scale+0.5))
+		h = max(1, int(float64(h)*scale+0.5))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+// thumbnail crops the centred square of src and scales it to at most size x size
+func thumbnail(src *image.RGBA, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	size = min(size, side)
+	return fit(src.SubImage(image.Rect(x, y, x+side, y+side)), size, size)
+}
+
+func longestSide(img image.Image) int {
+	return max(img.Bounds().Dx(), img.Bounds().Dy())
+}
+
+// orient turns src the way its EXIF orientation says it should be displayed
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // upside down and mirrored
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90° anticlockwise
+				sx, sy = w-1-y, x
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+
+	return dst
+}
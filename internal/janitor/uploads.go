@@ -0,0 +1,92 @@
+// Package janitor runs the background clean-up jobs of the API
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// uploadBatchSize is how many orphaned uploads are removed per query
+const uploadBatchSize = 100
+
+// UploadJanitor deletes uploads that no vendor, review or verification evidence has used within a
+// grace period, such as photos picked for a review that was never posted
+type UploadJanitor struct {
+	repository postgres.UploadRepository
+	storage    storage.Storage
+	grace      time.Duration
+	interval   time.Duration
+}
+
+func NewUploadJanitor(repository postgres.UploadRepository, store storage.Storage, grace, interval time.Duration) *UploadJanitor {
+	return &UploadJanitor{
+		repository: repository,
+		storage:    store,
+		grace:      grace,
+		interval:   interval,
+	}
+}
+
+// Run cleans up straight away and then every interval, until ctx is cancelled
+func (j *UploadJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		removed, err := j.Sweep(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to clean up orphaned uploads")
+		} else if removed > 0 {
+			log.Info().Int("removed", removed).Msg("Cleaned up orphaned uploads")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep removes every upload that has been unused for longer than the grace period, along with
+// the stored files no other upload shares, and returns how many it removed
+func (j *UploadJanitor) Sweep(ctx context.Context) (int, error) {
+	removed := 0
+	for {
+		batch, err := j.repository.DeleteOrphanedUploads(ctx, time.Now().Add(-j.grace), uploadBatchSize, func(objects []models.Upload) error {
+			return j.deleteFiles(ctx, objects)
+		})
+		if err != nil {
+			return removed, err
+		}
+
+		removed += batch
+		if batch < uploadBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+// deleteFiles removes stored files and their variants. A failure keeps their uploads, so they
+// are tried again on the next sweep.
+func (j *UploadJanitor) deleteFiles(ctx context.Context, objects []models.Upload) error {
+	for _, object := range objects {
+		keys := []string{object.FileName}
+		for name := range object.Variants {
+			keys = append(keys, models.VariantKey(object.FileName, name))
+		}
+		for _, key := range keys {
+			if err := j.storage.Delete(ctx, key); err != nil {
+				return fmt.Errorf("delete stored file %s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/aglili/waakye-directory/internal/auth"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Gin context keys holding the authenticated user
+const (
+	userIDKey   = "auth.user_id"
+	userRoleKey = "auth.role"
+)
+
+// RequireAuth rejects requests that do not carry a valid bearer access token
+func RequireAuth(tokens *auth.TokenManager) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			utils.RespondWithUnauthorized(ctx, "missing bearer token", "Authentication required")
+			ctx.Abort()
+			return
+		}
+
+		claims, err := tokens.ParseAccessToken(token)
+		if err != nil {
+			utils.RespondWithUnauthorized(ctx, err.Error(), "Authentication required")
+			ctx.Abort()
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			utils.RespondWithUnauthorized(ctx, err.Error(), "Authentication required")
+			ctx.Abort()
+			return
+		}
+
+		ctx.Set(userIDKey, userID)
+		ctx.Set(userRoleKey, claims.Role)
+		ctx.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated user's ID set by RequireAuth
+func CurrentUserID(ctx *gin.Context) (uuid.UUID, bool) {
+	value, exists := ctx.Get(userIDKey)
+	if !exists {
+		return uuid.Nil, false
+	}
+
+	userID, ok := value.(uuid.UUID)
+	return userID, ok
+}
+
+// CurrentRole returns the authenticated user's role set by RequireAuth
+func CurrentRole(ctx *gin.Context) (models.Role, bool) {
+	value, exists := ctx.Get(userRoleKey)
+	if !exists {
+		return "", false
+	}
+
+	role, ok := value.(models.Role)
+	return role, ok
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Permission is an action a route can require
+type Permission string
+
+const (
+	PermVendorCreate    Permission = "vendor:create"
+	PermVendorEditOwn   Permission = "vendor:edit_own"
+	PermVendorEditAny   Permission = "vendor:edit_any"
+	PermVendorDeleteOwn Permission = "vendor:delete_own"
+	PermVendorDeleteAny Permission = "vendor:delete_any"
+	PermVendorVerify    Permission = "vendor:verify"
+	PermVendorRestore   Permission = "vendor:restore"
+	PermVendorAssign    Permission = "vendor:assign_owner"
+	PermEvidenceSubmit  Permission = "verification:submit"
+	PermRatingCreate    Permission = "rating:create"
+	PermRatingModerate  Permission = "rating:moderate"
+	PermReviewReport    Permission = "review:report"
+	PermReviewReply     Permission = "review:reply"
+	PermReviewVote      Permission = "review:vote"
+	PermUploadCreate    Permission = "upload:create"
+	PermUserManage      Permission = "user:manage"
+)
+
+// rolePermissions declares what every role is allowed to do
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleAdmin: {
+		PermVendorCreate, PermVendorEditAny, PermVendorDeleteAny, PermVendorVerify,
+		PermVendorRestore, PermVendorAssign, PermEvidenceSubmit, PermRatingCreate, PermRatingModerate,
+		PermReviewReport, PermReviewVote, PermUploadCreate, PermUserManage,
+	},
+	models.RoleModerator: {
+		PermVendorCreate, PermVendorEditAny, PermVendorDeleteAny, PermVendorVerify,
+		PermEvidenceSubmit, PermRatingCreate, PermRatingModerate, PermReviewReport, PermReviewVote,
+		PermUploadCreate,
+	},
+	models.RoleVendorOwner: {
+		PermVendorCreate, PermVendorEditOwn, PermVendorDeleteOwn, PermEvidenceSubmit, PermRatingCreate,
+		PermReviewReport, PermReviewVote, PermReviewReply, PermUploadCreate,
+	},
+	models.RoleContributor: {
+		PermVendorCreate, PermEvidenceSubmit, PermRatingCreate, PermReviewReport, PermReviewVote,
+		PermUploadCreate,
+	},
+}
+
+// RoleHasPermission reports whether a role grants a permission
+func RoleHasPermission(role models.Role, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the authenticated user holds a permission
+func HasPermission(ctx *gin.Context, permission Permission) bool {
+	role, ok := CurrentRole(ctx)
+	return ok && RoleHasPermission(role, permission)
+}
+
+// RequirePermission only lets through authenticated users holding at least one of the permissions.
+// It must run after RequireAuth: a missing user is a 401, a user without the permission is a 403.
+func RequirePermission(permissions ...Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role, ok := CurrentRole(ctx)
+		if !ok {
+			utils.RespondWithUnauthorized(ctx, "no authenticated user in context", "Authentication required")
+			ctx.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if RoleHasPermission(role, permission) {
+				ctx.Next()
+				return
+			}
+		}
+
+		devMessage := fmt.Sprintf("role %q lacks any of %v", role, permissions)
+		utils.RespondWithForbidden(ctx, devMessage, "You do not have permission to perform this action")
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import "github.com/google/uuid"
+
+// BoundingBox is a map viewport in degrees, in GeoJSON order: west, south, east, north
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// MapPoint is either a single vendor or, when Count is above one, a cluster of vendors
+// positioned at their centroid
+type MapPoint struct {
+	ID            uuid.UUID
+	Name          string
+	Latitude      float64
+	Longitude     float64
+	AverageRating float64
+	IsVerified    bool
+	Count         int
+	CellKey       string
+}
+
+// FeatureCollection is an RFC 7946 GeoJSON feature collection. TotalItems, Clustered and Truncated
+// are foreign members telling the map how many vendors the viewport holds, how they were drawn and
+// whether some were left out.
+type FeatureCollection struct {
+	Type       string    `json:"type"`
+	BBox       []float64 `json:"bbox,omitempty"`
+	Features   []Feature `json:"features"`
+	TotalItems int64     `json:"total_items"`
+	Clustered  bool      `json:"clustered"`
+	Truncated  bool      `json:"truncated"`
+}
+
+// Feature is an RFC 7946 GeoJSON feature
+type Feature struct {
+	Type       string      `json:"type"`
+	ID         string      `json:"id,omitempty"`
+	Geometry   Point       `json:"geometry"`
+	Properties interface{} `json:"properties"`
+}
+
+// Point is a GeoJSON point. Coordinates are longitude then latitude.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// VendorFeatureProperties describes a single vendor on the map
+type VendorFeatureProperties struct {
+	Cluster       bool      `json:"cluster"`
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	AverageRating float64   `json:"average_rating"`
+	IsVerified    bool      `json:"is_verified"`
+}
+
+// ClusterFeatureProperties describes a group of vendors drawn as one marker
+type ClusterFeatureProperties struct {
+	Cluster    bool `json:"cluster"`
+	PointCount int  `json:"point_count"`
+}
+
+// NewPointFeature builds a feature for a map point, as a vendor or as a cluster
+func NewPointFeature(point MapPoint) Feature {
+	feature := Feature{
+		Type: "Feature",
+		Geometry: Point{
+			Type:        "Point",
+			Coordinates: [2]float64{point.Longitude, point.Latitude},
+		},
+	}
+
+	if point.Count > 1 {
+		feature.ID = "cluster:" + point.CellKey
+		feature.Properties = ClusterFeatureProperties{Cluster: true, PointCount: point.Count}
+		return feature
+	}
+
+	feature.ID = point.ID.String()
+	feature.Properties = VendorFeatureProperties{
+		ID:            point.ID,
+		Name:          point.Name,
+		AverageRating: point.AverageRating,
+		IsVerified:    point.IsVerified,
+	}
+	return feature
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VendorHoursSpan is one weekly opening. Weekday 0 is Sunday and times are HH:MM in Africa/Accra;
+// a closing time that is not after the opening time runs past midnight.
+type VendorHoursSpan struct {
+	Weekday  int    `json:"weekday" binding:"gte=0,lte=6"`
+	OpensAt  string `json:"opens_at" binding:"required"`
+	ClosesAt string `json:"closes_at" binding:"required"`
+}
+
+// VendorHoursOverride replaces the weekly hours from StartsOn to EndsOn (YYYY-MM-DD, inclusive)
+type VendorHoursOverride struct {
+	ID        uuid.UUID `json:"id"`
+	StartsOn  string    `json:"starts_on"`
+	EndsOn    string    `json:"ends_on"`
+	IsClosed  bool      `json:"is_closed"`
+	OpensAt   *string   `json:"opens_at,omitempty"`
+	ClosesAt  *string   `json:"closes_at,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VendorHours is a vendor's structured schedule with its current and upcoming overrides
+type VendorHours struct {
+	VendorID   uuid.UUID             `json:"vendor_id"`
+	Timezone   string                `json:"timezone"`
+	Weekly     []VendorHoursSpan     `json:"weekly"`
+	Overrides  []VendorHoursOverride `json:"overrides"`
+	IsOpenNow  bool                  `json:"is_open_now"`
+	NextOpenAt *time.Time            `json:"next_open_at"`
+}
+
+// SetVendorHoursRequest replaces a vendor's weekly hours; an empty list clears them
+type SetVendorHoursRequest struct {
+	Weekly []VendorHoursSpan `json:"weekly" binding:"max=42,dive"`
+}
+
+// CreateHoursOverrideRequest adds a holiday, temporary closure or one-off change of hours.
+// OpensAt and ClosesAt are required unless IsClosed is set.
+type CreateHoursOverrideRequest struct {
+	StartsOn string  `json:"starts_on" binding:"required"`
+	EndsOn   string  `json:"ends_on" binding:"required"`
+	IsClosed bool    `json:"is_closed"`
+	OpensAt  *string `json:"opens_at"`
+	ClosesAt *string `json:"closes_at"`
+	Note     string  `json:"note" binding:"max=255"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewStatus is whether a review is shown to the public
+type ReviewStatus string
+
+const (
+	// ReviewPublished reviews are shown and counted
+	ReviewPublished ReviewStatus = "published"
+	// ReviewFlagged reviews are still shown and counted but wait in the moderation queue
+	ReviewFlagged ReviewStatus = "flagged"
+	// ReviewHidden reviews are neither shown nor counted in a vendor's ratings
+	ReviewHidden ReviewStatus = "hidden"
+)
+
+// reviewTransitions lists the states a moderator can move each state to
+var reviewTransitions = map[ReviewStatus][]ReviewStatus{
+	ReviewPublished: {ReviewHidden},
+	ReviewFlagged:   {ReviewHidden, ReviewPublished},
+	ReviewHidden:    {ReviewPublished},
+}
+
+// IsValid reports whether s is one of the known review states
+func (s ReviewStatus) IsValid() bool {
+	_, ok := reviewTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a moderator can move a review from s to next
+func (s ReviewStatus) CanTransitionTo(next ReviewStatus) bool {
+	for _, allowed := range reviewTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Moderation log actions
+const (
+	ModerationFlagged  = "flagged"
+	ModerationReported = "reported"
+	ModerationHidden   = "hidden"
+	ModerationRestored = "restored"
+	ModerationDeleted  = "deleted"
+)
+
+type ReportReviewRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam abuse hate_speech competitor off_topic other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+type ModerationDecisionRequest struct {
+	Notes string `json:"notes" binding:"max=2000"`
+}
+
+type ReviewReport struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	RatingID   uuid.UUID  `json:"rating_id" db:"rating_id"`
+	ReporterID uuid.UUID  `json:"reporter_id" db:"reporter_id"`
+	Reason     string     `json:"reason" db:"reason"`
+	Details    string     `json:"details" db:"details"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+type ModerationLogEntry struct {
+	ID         uuid.UUID    `json:"id" db:"id"`
+	RatingID   uuid.UUID    `json:"rating_id" db:"rating_id"`
+	ActorID    *uuid.UUID   `json:"actor_id,omitempty" db:"actor_id"`
+	Action     string       `json:"action" db:"action"`
+	FromStatus ReviewStatus `json:"from_status" db:"from_status"`
+	ToStatus   ReviewStatus `json:"to_status" db:"to_status"`
+	Notes      string       `json:"notes" db:"notes"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+}
+
+// ReviewModeration is a review with its reports and moderation log
+type ReviewModeration struct {
+	Review  Review               `json:"review"`
+	Status  ReviewStatus         `json:"status"`
+	Reports []ReviewReport       `json:"reports"`
+	Log     []ModerationLogEntry `json:"log"`
+}
+
+// ModerationQueueItem is a review waiting on, or removed by, a moderator
+type ModerationQueueItem struct {
+	Review        Review       `json:"review"`
+	Status        ReviewStatus `json:"status"`
+	OpenReports   int          `json:"open_reports"`
+	Reasons       []string     `json:"reasons"`
+	LastChangedAt *time.Time   `json:"last_changed_at,omitempty"`
+}
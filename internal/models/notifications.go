@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	NotificationReviewReply = "review_reply"
+)
+
+// Notification is an in-app message for a user. VendorID and RatingID point at what it is about.
+type Notification struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Type      string     `json:"type" db:"type"`
+	Message   string     `json:"message" db:"message"`
+	VendorID  *uuid.UUID `json:"vendor_id,omitempty" db:"vendor_id"`
+	RatingID  *uuid.UUID `json:"rating_id,omitempty" db:"rating_id"`
+	ReadAt    *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
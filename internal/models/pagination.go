@@ -0,0 +1,19 @@
+package models
+
+import "github.com/google/uuid"
+
+// PageCursor is a decoded keyset cursor: the sort key and ID of the row a page continues from.
+// Backward cursors page towards the start of the listing.
+type PageCursor struct {
+	Sort     string    `json:"s"`
+	Value    string    `json:"v"`
+	ID       uuid.UUID `json:"id"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// KeysetPage holds the cursors around a page of keyset paginated results.
+// A nil cursor means there is nothing further in that direction.
+type KeysetPage struct {
+	Next *PageCursor
+	Prev *PageCursor
+}
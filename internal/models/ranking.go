@@ -0,0 +1,49 @@
+package models
+
+// RankingMethod is how top rated vendors are scored so a handful of ratings cannot beat a long track record
+type RankingMethod string
+
+const (
+	// RankingBayesian pulls every vendor's average towards the mean of its scope, weighted by PriorWeight
+	RankingBayesian RankingMethod = "bayesian"
+	// RankingWilson ranks by the lower bound of the 95% Wilson confidence interval of the average
+	RankingWilson RankingMethod = "wilson"
+)
+
+// Valid reports whether the method is supported
+func (m RankingMethod) Valid() bool {
+	return m == RankingBayesian || m == RankingWilson
+}
+
+// RatingDimension is the part of a rating vendors are ranked by
+type RatingDimension string
+
+const (
+	RatingDimensionOverall RatingDimension = "overall"
+	RatingDimensionTaste   RatingDimension = "taste"
+	RatingDimensionHygiene RatingDimension = "hygiene"
+	RatingDimensionValue   RatingDimension = "value"
+	RatingDimensionService RatingDimension = "service"
+)
+
+// Valid reports whether the dimension is supported
+func (d RatingDimension) Valid() bool {
+	switch d {
+	case RatingDimensionOverall, RatingDimensionTaste, RatingDimensionHygiene, RatingDimensionValue, RatingDimensionService:
+		return true
+	}
+	return false
+}
+
+// TopRatedParams ranks vendors with at least MinReviews ratings, optionally within a city or region.
+// PriorWeight is how many ratings' worth of the scope mean a Bayesian score starts from.
+type TopRatedParams struct {
+	Method      RankingMethod
+	Dimension   RatingDimension
+	MinReviews  int
+	PriorWeight float64
+	City        string
+	Region      string
+	Page        int
+	PageSize    int
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StarHistogram counts ratings by the stars they gave: index 0 holds the 1 star ratings and
+// index 4 the 5 star ratings
+type StarHistogram [5]int
+
+// RatingHistograms holds a star histogram for every rating dimension
+type RatingHistograms struct {
+	Hygiene StarHistogram `json:"hygiene"`
+	Value   StarHistogram `json:"value"`
+	Taste   StarHistogram `json:"taste"`
+	Service StarHistogram `json:"service"`
+}
+
+// RatingTrendBucket is how a rating trend groups ratings over time
+type RatingTrendBucket string
+
+const (
+	RatingTrendWeek  RatingTrendBucket = "week"
+	RatingTrendMonth RatingTrendBucket = "month"
+)
+
+// Valid reports whether the bucket is supported
+func (b RatingTrendBucket) Valid() bool {
+	return b == RatingTrendWeek || b == RatingTrendMonth
+}
+
+// RatingTrendParams asks for a vendor's ratings between two Africa/Accra dates, both inclusive
+type RatingTrendParams struct {
+	From   time.Time
+	To     time.Time
+	Bucket RatingTrendBucket
+}
+
+// RatingTrendPoint is the average of the ratings given within one bucket. Weeks start on Monday.
+// The averages are null for buckets without ratings.
+type RatingTrendPoint struct {
+	Start         time.Time `json:"start"`
+	RatingCount   int       `json:"rating_count"`
+	OverallRating *float64  `json:"overall_rating"`
+	HygieneRating *float64  `json:"hygiene_rating"`
+	ValueRating   *float64  `json:"value_rating"`
+	TasteRating   *float64  `json:"taste_rating"`
+	ServiceRating *float64  `json:"service_rating"`
+}
+
+// VendorRatingStats is a vendor's current star distribution and how its ratings moved over time
+type VendorRatingStats struct {
+	VendorID     uuid.UUID          `json:"vendor_id"`
+	TotalRatings int                `json:"total_ratings"`
+	LastRatedAt  *time.Time         `json:"last_rated_at"`
+	Histograms   RatingHistograms   `json:"histograms"`
+	Bucket       RatingTrendBucket  `json:"bucket"`
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	Trend        []RatingTrendPoint `json:"trend"`
+}
@@ -1,27 +1,76 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// RateVendorRequest is a rating with its optional photos: IDs of images the reviewer uploaded
+// through /api/v1/uploads
 type RateVendorRequest struct {
-	HygeineRating int    `json:"hygeine_rating" validate:"required,gte=1,lte=5" db:"hygeine_rating"`
-	ValueRating   int    `json:"value_rating" validate:"required,gte=1,lte=5" db:"value_rating"`
-	TasteRating   int    `json:"taste_rating" validate:"required,gte=1,lte=5" db:"taste_rating"`
-	ServiceRating int    `json:"service_rating" validate:"required,gte=1,lte=5" db:"service_rating"`
-	Comment       string `json:"comment" db:"comment"`
+	HygieneRating int          `json:"hygiene_rating" binding:"required,gte=1,lte=5" db:"hygiene_rating"`
+	ValueRating   int          `json:"value_rating" binding:"required,gte=1,lte=5" db:"value_rating"`
+	TasteRating   int          `json:"taste_rating" binding:"required,gte=1,lte=5" db:"taste_rating"`
+	ServiceRating int          `json:"service_rating" binding:"required,gte=1,lte=5" db:"service_rating"`
+	Comment       string       `json:"comment" binding:"max=2000" db:"comment"`
+	PhotoIDs      *[]uuid.UUID `json:"photo_ids" binding:"omitempty,max=5,unique" db:"-"`
+}
+
+// UnmarshalJSON also accepts the misspelt hygeine_rating key older clients send.
+// Deprecated: drop the fallback once clients have moved to hygiene_rating.
+func (r *RateVendorRequest) UnmarshalJSON(data []byte) error {
+	type plain RateVendorRequest
+	var request struct {
+		plain
+		LegacyHygieneRating *int `json:"hygeine_rating"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+
+	*r = RateVendorRequest(request.plain)
+	if r.HygieneRating == 0 && request.LegacyHygieneRating != nil {
+		r.HygieneRating = *request.LegacyHygieneRating
+	}
+	return nil
 }
 
 type VendorRatings struct {
-	HygieneRating float64         `json:"hygiene_rating" db:"hygiene_rating"`
-	ValueRating   float64         `json:"value_rating" db:"value_rating"`
-	TasteRating   float64         `json:"taste_rating" db:"taste_rating"`
-	ServiceRating float64         `json:"service_rating" db:"service_rating"`
-	OverallRating float64         `json:"overall_rating" db:"overall_rating"`
-	TotalRatings  int             `json:"total_ratings" db:"total_ratings"`
-	Comments      []VendorComment `json:"comments"`
+	HygieneRating float64          `json:"hygiene_rating" db:"hygiene_rating"`
+	ValueRating   float64          `json:"value_rating" db:"value_rating"`
+	TasteRating   float64          `json:"taste_rating" db:"taste_rating"`
+	ServiceRating float64          `json:"service_rating" db:"service_rating"`
+	OverallRating float64          `json:"overall_rating" db:"overall_rating"`
+	TotalRatings  int              `json:"total_ratings" db:"total_ratings"`
+	Histograms    RatingHistograms `json:"histograms" db:"-"`
+	LatestReviews []Review         `json:"latest_reviews"`
 }
 
-// New struct to hold individual comments
-type VendorComment struct {
-	Comment   string    `json:"comment" db:"comment"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+// RatingRevision is a snapshot of a rating taken just before it was edited or deleted
+type RatingRevision struct {
+	ID            uuid.UUID  `json:"id"`
+	RatingID      uuid.UUID  `json:"rating_id"`
+	EditorID      *uuid.UUID `json:"editor_id,omitempty"`
+	Action        string     `json:"action"`
+	HygieneRating int        `json:"hygiene_rating"`
+	ValueRating   int        `json:"value_rating"`
+	TasteRating   int        `json:"taste_rating"`
+	ServiceRating int        `json:"service_rating"`
+	Comment       string     `json:"comment"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Actions recorded in a rating's revision history
+const (
+	RatingRevisionUpdated = "updated"
+	RatingRevisionDeleted = "deleted"
+)
+
+// ResolveURLs fills in the photo URLs of the latest reviews
+func (r *VendorRatings) ResolveURLs(url func(key string) string) {
+	for i := range r.LatestReviews {
+		r.LatestReviews[i].ResolveURLs(url)
+	}
 }
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review is a rating as every endpoint returns it, whether listed, embedded in a vendor or
+// returned after being written
+type Review struct {
+	ID           uuid.UUID     `json:"id"`
+	VendorID     uuid.UUID     `json:"vendor_id"`
+	Author       *ReviewAuthor `json:"author"`
+	Scores       ReviewScores  `json:"scores"`
+	Comment      string        `json:"comment"`
+	Reply        *ReviewReply  `json:"reply"`
+	Photos       []ReviewPhoto `json:"photos"`
+	HelpfulCount int           `json:"helpful_count"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// ReviewPhoto is an uploaded image attached to a review. Key is where it is stored; its URLs are
+// filled in by ResolveURLs.
+type ReviewPhoto struct {
+	ID       uuid.UUID     `json:"id"`
+	Key      string        `json:"-"`
+	URL      string        `json:"url"`
+	Variants ImageVariants `json:"variants,omitempty"`
+	Srcset   string        `json:"srcset,omitempty"`
+}
+
+// ResolveURLs fills in the URLs of the review's photos
+func (r *Review) ResolveURLs(url func(key string) string) {
+	for i := range r.Photos {
+		photo := &r.Photos[i]
+		photo.URL = url(photo.Key)
+		photo.Srcset = photo.Variants.ResolveURLs(photo.Key, url)
+	}
+}
+
+// ReviewReply is the vendor owner's public answer to a review. It is null until the owner replies.
+type ReviewReply struct {
+	ID        uuid.UUID `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HelpfulVote is a user's helpful vote on a review after marking or unmarking it
+type HelpfulVote struct {
+	RatingID     uuid.UUID `json:"rating_id"`
+	Helpful      bool      `json:"helpful"`
+	HelpfulCount int       `json:"helpful_count"`
+}
+
+type ReviewReplyRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=2000"`
+}
+
+// ReviewAuthor is the public face of the user who wrote a review. It is null for reviews left
+// before ratings were tied to accounts.
+type ReviewAuthor struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+}
+
+// ReviewScores are a review's stars per dimension and their mean
+type ReviewScores struct {
+	Overall float64 `json:"overall"`
+	Hygiene int     `json:"hygiene"`
+	Value   int     `json:"value"`
+	Taste   int     `json:"taste"`
+	Service int     `json:"service"`
+}
+
+type ReviewSort string
+
+const (
+	ReviewSortNewest  ReviewSort = "newest"
+	ReviewSortHighest ReviewSort = "highest"
+	ReviewSortLowest  ReviewSort = "lowest"
+	// ReviewSortMostHelpful puts the reviews most users marked as helpful first
+	ReviewSortMostHelpful ReviewSort = "most_helpful"
+)
+
+// ReviewFilter narrows down a review listing. Nil fields are not applied.
+type ReviewFilter struct {
+	MinStars   *int
+	HasComment *bool
+}
+
+// ReviewListParams pages through a vendor's reviews.
+// When UseCursor is set the listing is keyset paginated from Cursor, or from the start when it is nil.
+type ReviewListParams struct {
+	Sort      ReviewSort
+	Filter    ReviewFilter
+	Page      int
+	PageSize  int
+	UseCursor bool
+	Cursor    *PageCursor
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload is a file stored through /api/v1/uploads. Checksum is the SHA-256 of the stored file,
+// which is the upload with its GPS location removed, and names it in storage.
+type Upload struct {
+	ID           uuid.UUID     `json:"id" db:"id"`
+	OwnerID      *uuid.UUID    `json:"owner_id,omitempty" db:"owner_id"`
+	FileName     string        `json:"file_name" db:"file_name"`
+	OriginalName string        `json:"original_name" db:"original_name"`
+	ContentType  string        `json:"content_type" db:"content_type"`
+	SizeBytes    int64         `json:"size_bytes" db:"size_bytes"`
+	Checksum     string        `json:"checksum" db:"checksum"`
+	Width        *int          `json:"width" db:"width"`
+	Height       *int          `json:"height" db:"height"`
+	FileURL      string        `json:"file_url" db:"-"`
+	Variants     ImageVariants `json:"variants" db:"variants"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+}
+
+// Names of the resized variants generated for uploaded images
+const (
+	ImageVariantThumb  = "thumb"
+	ImageVariantSmall  = "small"
+	ImageVariantMedium = "medium"
+	ImageVariantLarge  = "large"
+)
+
+// ImageVariant is a resized JPEG copy of an uploaded image. Only its size is stored; the URL is
+// filled in by ResolveURLs when responding.
+type ImageVariant struct {
+	URL    string `json:"url,omitempty"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ImageVariants are the resized copies of an image keyed by variant name. Images that could not
+// be resized, such as HEIC photos, have none.
+type ImageVariants map[string]ImageVariant
+
+// VariantKey names the stored file of an original's variant
+func VariantKey(originalKey, variant string) string {
+	return strings.TrimSuffix(originalKey, path.Ext(originalKey)) + "_" + variant + ".jpg"
+}
+
+// ResolveURLs fills in the URL of every variant of the image stored under key and returns their
+// srcset
+func (v ImageVariants) ResolveURLs(key string, url func(key string) string) string {
+	for name, variant := range v {
+		variant.URL = url(VariantKey(key, name))
+		v[name] = variant
+	}
+	return v.Srcset()
+}
+
+// ResolveURLs fills in the URLs of the stored file and its variants
+func (u *Upload) ResolveURLs(url func(key string) string) {
+	u.FileURL = url(u.FileName)
+	u.Variants.ResolveURLs(u.FileName, url)
+}
+
+// Srcset lists the variants as an HTML srcset attribute, narrowest first. The thumb is left out
+// because it is cropped square.
+func (v ImageVariants) Srcset() string {
+	variants := make([]ImageVariant, 0, len(v))
+	for name, variant := range v {
+		if name != ImageVariantThumb {
+			variants = append(variants, variant)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].Width < variants[j].Width })
+
+	candidates := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+	}
+	return strings.Join(candidates, ", ")
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleAdmin       Role = "admin"
+	RoleModerator   Role = "moderator"
+	RoleVendorOwner Role = "vendor_owner"
+	RoleContributor Role = "contributor"
+)
+
+// IsValid reports whether r is one of the known roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleModerator, RoleVendorOwner, RoleContributor:
+		return true
+	}
+	return false
+}
+
+type User struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Email        string    `json:"email" db:"email"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	DisplayName  string    `json:"display_name" db:"display_name"`
+	Role         Role      `json:"role" db:"role"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type SignupRequest struct {
+	Email       string `json:"email" binding:"required,email,max=255"`
+	Password    string `json:"password" binding:"required,min=8,max=72"`
+	DisplayName string `json:"display_name" binding:"required,max=100"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=admin moderator vendor_owner contributor"`
+}
+
+type AssignVendorOwnerRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+// AuthTokens is returned after a successful signup, login or refresh
+type AuthTokens struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	TokenType             string    `json:"token_type"`
+	User                  *User     `json:"user"`
+}
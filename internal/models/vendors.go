@@ -1,8 +1,9 @@
 package models
 
 import (
-	"github.com/google/uuid"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Location struct {
@@ -17,35 +18,151 @@ type Location struct {
 }
 
 type WaakyeVendor struct {
-	ID             uuid.UUID `json:"id" db:"id"`
-	Name           string    `json:"name" db:"name"`
-	LocationID     uuid.UUID `json:"location_id" db:"location_id"`
-	Location       Location  `json:"location" db:"-"`
-	Description    string    `json:"description" db:"description"`
-	OperatingHours string    `json:"operating_hours" db:"operating_hours"`
-	ImageURL       string    `json:"image_url" db:"image_url"`
-	PhoneNumber    string    `json:"phone_number" db:"phone_number"`
-	IsVerified     bool      `json:"is_verified" db:"is_verified"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
-	Distance       float64   `json:"distance_km,omitempty" db:"-"`
-	AverageRating float64   `json:"average_rating" db:"average_rating"`
-	AverageHygieneRating float64 `json:"average_hygiene_rating" db:"average_hygiene_rating"`
-	AverageValueRating float64 `json:"average_value_rating" db:"average_value_rating"`
-	AverageTasteRating float64 `json:"average_taste_rating" db:"average_taste_rating"`
-	AverageServiceRating float64 `json:"average_service_rating" db:"average_service_rating"`
-	Ratings []VendorRating `json:"ratings" db:"-"`
-}
-
-
-
-
-type VendorRating struct {
-    ID            uuid.UUID
-    HygieneRating float32
-    ValueRating   float32
-    ServiceRating float32
-    TasteRating   float32
-    Comment       string
-    CreatedAt     time.Time
-}
\ No newline at end of file
+	ID                   uuid.UUID          `json:"id" db:"id"`
+	Name                 string             `json:"name" db:"name"`
+	LocationID           uuid.UUID          `json:"location_id" db:"location_id"`
+	Location             Location           `json:"location" db:"-"`
+	Description          string             `json:"description" db:"description"`
+	OperatingHours       string             `json:"operating_hours" db:"operating_hours"`
+	ImageID              *uuid.UUID         `json:"image_id" db:"image_upload_id"`
+	ImageKey             string             `json:"-" db:"-"`
+	ImageURL             string             `json:"image_url" db:"image_url"`
+	ImageVariants        ImageVariants      `json:"image_variants,omitempty" db:"-"`
+	ImageSrcset          string             `json:"image_srcset,omitempty" db:"-"`
+	PhoneNumber          string             `json:"phone_number" db:"phone_number"`
+	IsOpenNow            *bool              `json:"is_open_now" db:"-"`
+	NextOpenAt           *time.Time         `json:"next_open_at" db:"-"`
+	IsVerified           bool               `json:"is_verified" db:"-"`
+	VerificationStatus   VerificationStatus `json:"verification_status" db:"verification_status"`
+	CreatedBy            *uuid.UUID         `json:"created_by,omitempty" db:"created_by"`
+	OwnerID              *uuid.UUID         `json:"owner_id,omitempty" db:"owner_id"`
+	CreatedAt            time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time          `json:"updated_at" db:"updated_at"`
+	DeletedAt            *time.Time         `json:"deleted_at,omitempty" db:"deleted_at"`
+	Distance             float64            `json:"distance_km,omitempty" db:"-"`
+	SearchScore          float64            `json:"search_score,omitempty" db:"-"`
+	RankScore            float64            `json:"rank_score,omitempty" db:"-"`
+	AverageRating        float64            `json:"average_rating" db:"average_rating"`
+	ReviewCount          int                `json:"review_count" db:"review_count"`
+	AverageHygieneRating float64            `json:"average_hygiene_rating" db:"average_hygiene_rating"`
+	AverageValueRating   float64            `json:"average_value_rating" db:"average_value_rating"`
+	AverageTasteRating   float64            `json:"average_taste_rating" db:"average_taste_rating"`
+	AverageServiceRating float64            `json:"average_service_rating" db:"average_service_rating"`
+	LatestReviews        []Review           `json:"latest_reviews" db:"-"`
+	Gallery              []VendorPhoto      `json:"gallery,omitempty" db:"-"`
+}
+
+// Sources of the photos in a vendor's gallery
+const (
+	VendorPhotoSourceVendor = "vendor"
+	VendorPhotoSourceReview = "review"
+)
+
+// ResolveURLs fills in the URLs of the vendor's image, gallery and latest reviews. A vendor whose
+// image was given as a link before images were uploads keeps that link in ImageURL and has no key.
+func (v *WaakyeVendor) ResolveURLs(url func(key string) string) {
+	if v.ImageKey != "" {
+		v.ImageURL = url(v.ImageKey)
+		v.ImageSrcset = v.ImageVariants.ResolveURLs(v.ImageKey, url)
+	}
+	for i := range v.Gallery {
+		photo := &v.Gallery[i]
+		if photo.Key != "" {
+			photo.URL = url(photo.Key)
+			photo.Srcset = photo.Variants.ResolveURLs(photo.Key, url)
+		}
+	}
+	for i := range v.LatestReviews {
+		v.LatestReviews[i].ResolveURLs(url)
+	}
+}
+
+// VendorPhoto is one picture in a vendor's gallery: the listing's own image or a photo attached
+// to one of its reviews
+type VendorPhoto struct {
+	Key       string        `json:"-"`
+	URL       string        `json:"url"`
+	Variants  ImageVariants `json:"variants,omitempty"`
+	Srcset    string        `json:"srcset,omitempty"`
+	Source    string        `json:"source"`
+	RatingID  *uuid.UUID    `json:"rating_id,omitempty"`
+	CreatedAt *time.Time    `json:"created_at,omitempty"`
+}
+
+// UpdateLocationRequest holds the location fields that can be changed on a vendor.
+// Nil fields are left untouched.
+type UpdateLocationRequest struct {
+	StreetAddress *string  `json:"street_address"`
+	City          *string  `json:"city"`
+	Region        *string  `json:"region"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+	Landmark      *string  `json:"landmark"`
+}
+
+// UpdateVendorRequest holds a partial update for a vendor and its location.
+// Nil fields are left untouched. An empty ImageID removes the vendor's image.
+type UpdateVendorRequest struct {
+	Name           *string                `json:"name"`
+	Description    *string                `json:"description"`
+	OperatingHours *string                `json:"operating_hours"`
+	ImageID        *string                `json:"image_id" binding:"omitempty,uuid"`
+	PhoneNumber    *string                `json:"phone_number"`
+	Location       *UpdateLocationRequest `json:"location"`
+}
+
+// VendorSearchParams holds a free text vendor search with optional proximity boosting
+type VendorSearchParams struct {
+	Query     string
+	Latitude  *float64
+	Longitude *float64
+	Page      int
+	PageSize  int
+}
+
+type VendorSort string
+
+const (
+	VendorSortNewest   VendorSort = "newest"
+	VendorSortRating   VendorSort = "rating"
+	VendorSortName     VendorSort = "name"
+	VendorSortDistance VendorSort = "distance"
+	VendorSortReviews  VendorSort = "reviews"
+)
+
+// VendorFilter narrows down a vendor listing. Zero values are not applied.
+type VendorFilter struct {
+	City       string
+	Region     string
+	IsVerified *bool
+	MinRating  *float64
+	HasImage   *bool
+	OpenNow    *bool
+}
+
+// NearbyParams finds vendors around a point. In radius mode every vendor within RadiusKm is
+// returned; in nearest mode the Limit closest vendors are returned however far away they are.
+// Limit caps the total number of results across pages, zero meaning no cap.
+type NearbyParams struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	Nearest   bool
+	Limit     int
+	Filter    VendorFilter
+	Page      int
+	PageSize  int
+}
+
+// VendorListParams holds the filters, sort and page of a vendor listing.
+// When UseCursor is set the listing is keyset paginated from Cursor, or from the start when it is nil.
+type VendorListParams struct {
+	Filter    VendorFilter
+	Sort      VendorSort
+	Latitude  *float64
+	Longitude *float64
+	Page      int
+	PageSize  int
+	UseCursor bool
+	Cursor    *PageCursor
+}
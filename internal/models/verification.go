@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VerificationStatus string
+
+const (
+	VerificationPending     VerificationStatus = "pending"
+	VerificationUnderReview VerificationStatus = "under_review"
+	VerificationVerified    VerificationStatus = "verified"
+	VerificationRejected    VerificationStatus = "rejected"
+)
+
+// verificationTransitions lists the states each state can move to
+var verificationTransitions = map[VerificationStatus][]VerificationStatus{
+	VerificationPending:     {VerificationUnderReview, VerificationVerified, VerificationRejected},
+	VerificationUnderReview: {VerificationVerified, VerificationRejected},
+	VerificationVerified:    {VerificationRejected},
+	VerificationRejected:    {VerificationUnderReview},
+}
+
+// IsValid reports whether s is one of the known verification states
+func (s VerificationStatus) IsValid() bool {
+	_, ok := verificationTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether the workflow allows moving from s to next
+func (s VerificationStatus) CanTransitionTo(next VerificationStatus) bool {
+	for _, allowed := range verificationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// VerificationEvidence is a photo backing up a verification request. Evidence submitted before it
+// referenced uploads has no UploadID and keeps the link it was given in FileURL.
+type VerificationEvidence struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	VendorID    uuid.UUID  `json:"vendor_id" db:"vendor_id"`
+	UploadID    *uuid.UUID `json:"upload_id" db:"upload_id"`
+	Key         string     `json:"-" db:"-"`
+	FileURL     string     `json:"file_url" db:"file_url"`
+	Caption     string     `json:"caption" db:"caption"`
+	SubmittedBy *uuid.UUID `json:"submitted_by,omitempty" db:"submitted_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+type VerificationHistoryEntry struct {
+	ID         uuid.UUID          `json:"id" db:"id"`
+	VendorID   uuid.UUID          `json:"vendor_id" db:"vendor_id"`
+	FromStatus VerificationStatus `json:"from_status" db:"from_status"`
+	ToStatus   VerificationStatus `json:"to_status" db:"to_status"`
+	ActorID    *uuid.UUID         `json:"actor_id,omitempty" db:"actor_id"`
+	Notes      string             `json:"notes" db:"notes"`
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+}
+
+// VendorVerification is the full verification record of a vendor
+type VendorVerification struct {
+	VendorID   uuid.UUID                  `json:"vendor_id"`
+	VendorName string                     `json:"vendor_name"`
+	Status     VerificationStatus         `json:"status"`
+	Evidence   []VerificationEvidence     `json:"evidence"`
+	History    []VerificationHistoryEntry `json:"history"`
+}
+
+// ResolveURLs fills in the URLs of the uploaded evidence
+func (v *VendorVerification) ResolveURLs(url func(key string) string) {
+	for i := range v.Evidence {
+		if v.Evidence[i].Key != "" {
+			v.Evidence[i].FileURL = url(v.Evidence[i].Key)
+		}
+	}
+}
+
+// VerificationQueueItem is a vendor waiting on a verification decision
+type VerificationQueueItem struct {
+	VendorID      uuid.UUID          `json:"vendor_id"`
+	VendorName    string             `json:"vendor_name"`
+	City          string             `json:"city"`
+	Region        string             `json:"region"`
+	Status        VerificationStatus `json:"status"`
+	EvidenceCount int                `json:"evidence_count"`
+	LastChangedAt *time.Time         `json:"last_changed_at,omitempty"`
+}
+
+type EvidenceInput struct {
+	UploadID uuid.UUID `json:"upload_id" binding:"required"`
+	Caption  string    `json:"caption" binding:"max=500"`
+}
+
+type SubmitVerificationRequest struct {
+	Evidence []EvidenceInput `json:"evidence" binding:"required,min=1,max=10,dive"`
+	Notes    string          `json:"notes" binding:"max=2000"`
+}
+
+type VerificationDecisionRequest struct {
+	Notes string `json:"notes" binding:"max=2000"`
+}
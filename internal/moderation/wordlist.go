@@ -0,0 +1,126 @@
+// Package moderation decides which reviews need a moderator's attention before anyone reports them.
+package moderation
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed wordlist.txt
+var defaultTerms string
+
+// Wordlist is a set of words and phrases, in English, Twi and Pidgin, that flag a review
+type Wordlist struct {
+	terms []string
+}
+
+// NewWordlist returns the built-in wordlist extended with the terms in extra
+func NewWordlist(extra ...string) *Wordlist {
+	seen := map[string]bool{}
+	list := &Wordlist{}
+	for _, term := range append(parseTerms(defaultTerms), extra...) {
+		term = normalize(term)
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		list.terms = append(list.terms, term)
+	}
+	sort.Strings(list.terms)
+	return list
+}
+
+// LoadWordlist returns the built-in wordlist extended with the terms in a file, written one per
+// line like the built-in list. An empty path loads only the built-in list.
+func LoadWordlist(path string) (*Wordlist, error) {
+	if path == "" {
+		return NewWordlist(), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read moderation wordlist: %w", err)
+	}
+
+	return NewWordlist(parseTerms(string(content))...), nil
+}
+
+// Match returns the terms found in text, in alphabetical order
+func (w *Wordlist) Match(text string) []string {
+	// Padding both sides with spaces makes every match a whole word or phrase
+	padded := " " + normalize(text) + " "
+	if strings.TrimSpace(padded) == "" {
+		return nil
+	}
+
+	var matches []string
+	for _, term := range w.terms {
+		if strings.Contains(padded, " "+term+" ") {
+			matches = append(matches, term)
+		}
+	}
+	return matches
+}
+
+// parseTerms reads one term per line, skipping blank lines and # comments
+func parseTerms(content string) []string {
+	var terms []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms
+}
+
+// letterFolds spells Twi vowels the way the wordlist does
+var letterFolds = map[rune]rune{
+	'ɛ': 'e', 'Ɛ': 'e', 'ɔ': 'o', 'Ɔ': 'o',
+}
+
+// lookAlikeFolds undo common character swaps such as "sh1t". They only apply inside words that
+// also contain letters, so prices and times like "10.50" or "4:30" are left alone.
+var lookAlikeFolds = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '@': 'a', '$': 's',
+}
+
+// normalize lowercases text, folds Twi letters and look-alike characters, and collapses
+// everything that is not a letter into single spaces
+func normalize(text string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isWordBreak) {
+		foldLookAlikes := strings.IndexFunc(word, unicode.IsLetter) >= 0
+		gap := true
+		for _, r := range word {
+			if folded, ok := letterFolds[r]; ok {
+				r = folded
+			} else if folded, ok := lookAlikeFolds[r]; ok && foldLookAlikes {
+				r = folded
+			}
+			if !unicode.IsLetter(r) {
+				gap = true
+				continue
+			}
+			if gap && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			gap = false
+		}
+	}
+	return b.String()
+}
+
+// isWordBreak reports whether r separates words: anything but letters, digits and look-alikes
+func isWordBreak(r rune) bool {
+	if _, ok := lookAlikeFolds[r]; ok {
+		return false
+	}
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
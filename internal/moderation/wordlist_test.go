@@ -0,0 +1,120 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Stupid!", "stupid"},
+		{"  GO   to\nhell ", "go to hell"},
+		{"sh1t", "shit"},
+		{"B1TCH", "bitch"},
+		{"@$hawo", "ashawo"},
+		{"m3ss", "mess"},
+		{"Ɛtwe ne ɔboa", "etwe ne oboa"},
+		{"100 cedis", "cedis"},
+		{"paid 10.50 at 4:30", "paid at"},
+		{"5 stars", "stars"},
+		{"f*ck-ing", "f ck ing"},
+		{"", ""},
+		{"12345 !!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := normalize(tt.text); got != tt.want {
+				t.Errorf("normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	list := NewWordlist()
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"plain word", "The seller is stupid", []string{"stupid"}},
+		{"punctuation around a word", "Stupid!!", []string{"stupid"}},
+		{"look-alike spelling", "this waakye is sh1t", []string{"shit"}},
+		{"phrase", "Go to hell, chale", []string{"go to hell"}},
+		{"phrase across line breaks", "go to\nhell", []string{"go to hell"}},
+		{"Twi letters", "Ɛtwe", []string{"etwe"}},
+		{"several terms in order", "kwasia, aboa", []string{"aboa", "kwasia"}},
+		{"term repeated", "mumu mumu mumu", []string{"mumu"}},
+		{"longer word", "such stupidity", nil},
+		{"word inside another", "shitake mushrooms", nil},
+		{"phrase inside a longer one", "go to hello kitty", nil},
+		{"price", "100 cedis for one plate", nil},
+		{"times and prices", "opens 5:30, 10.50 a plate, 4 stars", nil},
+		{"empty", "", nil},
+		{"only punctuation", "?!...", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := list.Match(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMatchIgnoresComplaints checks words reviewers use to describe a bad experience, which were
+// taken off the list because they flagged honest reviews
+func TestMatchIgnoresComplaints(t *testing.T) {
+	list := NewWordlist()
+
+	for _, text := range []string{
+		"scam", "scammer", "fraud", "thief", "thieves",
+		"wo maame", "your mama", "your papa", "ohiani",
+		"The seller is a thief and the price is a scam",
+	} {
+		t.Run(text, func(t *testing.T) {
+			if got := list.Match(text); got != nil {
+				t.Errorf("Match(%q) = %v, want no matches", text, got)
+			}
+		})
+	}
+}
+
+func TestNewWordlistExtraTerms(t *testing.T) {
+	list := NewWordlist("Chale Wote", "  ", "STUPID")
+
+	if got, want := list.Match("chale-WOTE stupid"), []string{"chale wote", "stupid"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match = %v, want %v", got, want)
+	}
+}
+
+func TestLoadWordlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wordlist.txt")
+	content := "# extra terms\n\nchop money\n  # indented comment\nwahala\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := LoadWordlist(path)
+	if err != nil {
+		t.Fatalf("LoadWordlist: %v", err)
+	}
+	if got, want := list.Match("No wahala, just chop money. Idiot!"), []string{"chop money", "idiot", "wahala"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match = %v, want %v", got, want)
+	}
+	if got := list.Match("indented comment"); got != nil {
+		t.Errorf("a comment line was loaded as a term: %v", got)
+	}
+
+	if _, err := LoadWordlist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadWordlist of a missing file returned no error")
+	}
+}
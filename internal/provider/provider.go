@@ -3,29 +3,68 @@ package provider
 import (
 	"database/sql"
 
+	"github.com/aglili/waakye-directory/internal/auth"
 	"github.com/aglili/waakye-directory/internal/config"
 	"github.com/aglili/waakye-directory/internal/handlers"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/moderation"
 	"github.com/aglili/waakye-directory/internal/repository/postgres"
+	"github.com/aglili/waakye-directory/internal/storage"
 )
 
 type Provider struct {
-	Cfg           *config.Config
-	DB            *sql.DB
-	UploadHandler *handlers.UploadHandler
-	VendorHandler *handlers.VendorHandler
+	Cfg                 *config.Config
+	DB                  *sql.DB
+	Tokens              *auth.TokenManager
+	AuthHandler         *handlers.AuthHandler
+	ModerationHandler   *handlers.ModerationHandler
+	NotificationHandler *handlers.NotificationHandler
+	ReviewReplyHandler  *handlers.ReviewReplyHandler
+	UploadHandler       *handlers.UploadHandler
+	UserHandler         *handlers.UserHandler
+	VendorHandler       *handlers.VendorHandler
+	VerificationHandler *handlers.VerificationHandler
 }
 
-func NewProvider(db *sql.DB, cfg *config.Config) *Provider {
+func NewProvider(db *sql.DB, cfg *config.Config, wordlist *moderation.Wordlist, store storage.Storage) *Provider {
 	vendorRepository := postgres.NewVendorRepository(db)
 	ratingsRepository := postgres.NewRatingRepository(db)
+	userRepository := postgres.NewUserRepository(db)
+	verificationRepository := postgres.NewVerificationRepository(db)
+	hoursRepository := postgres.NewHoursRepository(db)
+	moderationRepository := postgres.NewModerationRepository(db)
+	reviewReplyRepository := postgres.NewReviewReplyRepository(db)
+	notificationRepository := postgres.NewNotificationRepository(db)
+	uploadRepository := postgres.NewUploadRepository(db)
 
-	vendorHandler := handlers.NewVendorHandler(vendorRepository, ratingsRepository)
-	uploadHandler := handlers.NewUploadHandler(cfg.FileUploadPath)
+	tokens := auth.NewTokenManager(cfg.JWTSecret, cfg.AccessTokenTTL)
+
+	authHandler := handlers.NewAuthHandler(userRepository, tokens, cfg.RefreshTokenTTL)
+	userHandler := handlers.NewUserHandler(userRepository)
+	ranking := models.TopRatedParams{
+		Method:      models.RankingMethod(cfg.RankingMethod),
+		MinReviews:  cfg.RankingMinReviews,
+		PriorWeight: cfg.RankingPriorWeight,
+	}
+
+	vendorHandler := handlers.NewVendorHandler(vendorRepository, ratingsRepository, hoursRepository, moderationRepository, wordlist, ranking, store)
+	verificationHandler := handlers.NewVerificationHandler(verificationRepository, store)
+	moderationHandler := handlers.NewModerationHandler(moderationRepository, store)
+	reviewReplyHandler := handlers.NewReviewReplyHandler(reviewReplyRepository, vendorRepository, store)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepository)
+	uploadHandler := handlers.NewUploadHandler(uploadRepository, store)
 
 	return &Provider{
-		DB:            db,
-		VendorHandler: vendorHandler,
-		UploadHandler: uploadHandler,
-		Cfg:           cfg,
+		DB:                  db,
+		Tokens:              tokens,
+		AuthHandler:         authHandler,
+		ModerationHandler:   moderationHandler,
+		NotificationHandler: notificationHandler,
+		ReviewReplyHandler:  reviewReplyHandler,
+		UserHandler:         userHandler,
+		VendorHandler:       vendorHandler,
+		UploadHandler:       uploadHandler,
+		VerificationHandler: verificationHandler,
+		Cfg:                 cfg,
 	}
 }
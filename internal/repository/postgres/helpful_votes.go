@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrOwnReview is returned when users vote on their own review
+var ErrOwnReview = errors.New("cannot vote on own review")
+
+// RepairHelpfulCounts recounts every review's helpful votes and returns how many reviews were off.
+// Votes deleted along with their user are not subtracted from helpful_count, so this is needed
+// after users are removed.
+func (r *ratingsRepository) RepairHelpfulCounts(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin helpful count repair")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Block votes until the recounted totals are committed so none are lost in between
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE review_helpful_votes IN SHARE MODE`); err != nil {
+		log.Error().Err(err).Msg("Failed to lock helpful votes for repair")
+		return 0, err
+	}
+
+	query := `
+		UPDATE vendor_ratings vr
+		SET helpful_count = counted.votes
+		FROM (
+			SELECT cr.id, COUNT(hv.user_id) AS votes
+			FROM vendor_ratings cr
+			LEFT JOIN review_helpful_votes hv ON hv.rating_id = cr.id
+			GROUP BY cr.id
+		) counted
+		WHERE counted.id = vr.id AND vr.helpful_count <> counted.votes
+	`
+
+	result, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to recount helpful votes")
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit helpful count repair")
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// SetHelpfulVote marks a visible review as helpful for a user, or takes the vote back when helpful
+// is false. Voting twice either way changes nothing. The review's helpful_count moves with the vote
+// in the same transaction.
+func (r *ratingsRepository) SetHelpfulVote(ctx context.Context, vendorID, ratingID, userID uuid.UUID, helpful bool) (*models.HelpfulVote, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin helpful vote transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lockQuery := fmt.Sprintf(`
+		SELECT vr.user_id
+		FROM vendor_ratings vr
+		WHERE vr.id = $1 AND vr.vendor_id = $2 AND %s
+		FOR UPDATE
+	`, reviewVisible)
+
+	var authorID *uuid.UUID
+	if err := tx.QueryRowContext(ctx, lockQuery, ratingID, vendorID).Scan(&authorID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRatingNotFound
+		}
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to lock review for helpful vote")
+		return nil, err
+	}
+	if helpful && authorID != nil && *authorID == userID {
+		return nil, ErrOwnReview
+	}
+
+	voteQuery := `DELETE FROM review_helpful_votes WHERE rating_id = $1 AND user_id = $2`
+	delta := -1
+	if helpful {
+		voteQuery = `INSERT INTO review_helpful_votes (rating_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		delta = 1
+	}
+
+	result, err := tx.ExecContext(ctx, voteQuery, ratingID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to record helpful vote")
+		return nil, err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if changed == 0 {
+		delta = 0
+	}
+
+	vote := models.HelpfulVote{RatingID: ratingID, Helpful: helpful}
+	err = tx.QueryRowContext(ctx,
+		`UPDATE vendor_ratings SET helpful_count = helpful_count + $2 WHERE id = $1 RETURNING helpful_count`,
+		ratingID, delta,
+	).Scan(&vote.HelpfulCount)
+	if err != nil {
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to update helpful count")
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit helpful vote")
+		return nil, err
+	}
+
+	return &vote, nil
+}
@@ -0,0 +1,381 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/hours"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrHoursOverrideNotFound is returned when an hours override does not exist for the vendor
+var ErrHoursOverrideNotFound = errors.New("hours override not found")
+
+type HoursRepository interface {
+	GetVendorHours(ctx context.Context, vendorID uuid.UUID) (*models.VendorHours, error)
+	ReplaceWeeklyHours(ctx context.Context, vendorID uuid.UUID, spans []hours.Span) error
+	AddOverride(ctx context.Context, vendorID, createdBy uuid.UUID, override hours.Override, note string) (*models.VendorHoursOverride, error)
+	DeleteOverride(ctx context.Context, vendorID, overrideID uuid.UUID) error
+	LoadSchedules(ctx context.Context, vendorIDs []uuid.UUID) (map[uuid.UUID]hours.Schedule, error)
+	ListVendorsWithoutHours(ctx context.Context) (map[uuid.UUID]string, error)
+}
+
+type hoursRepository struct {
+	db *sql.DB
+}
+
+func NewHoursRepository(db *sql.DB) HoursRepository {
+	return &hoursRepository{
+		db: db,
+	}
+}
+
+func (r *hoursRepository) GetVendorHours(ctx context.Context, vendorID uuid.UUID) (*models.VendorHours, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM waakye_vendors WHERE id = $1 AND deleted_at IS NULL)`, vendorID,
+	).Scan(&exists)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check vendor for hours")
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrVendorNotFound
+	}
+
+	schedules, err := r.LoadSchedules(ctx, []uuid.UUID{vendorID})
+	if err != nil {
+		return nil, err
+	}
+	schedule := schedules[vendorID]
+
+	result := &models.VendorHours{
+		VendorID:  vendorID,
+		Timezone:  hours.Accra.String(),
+		Weekly:    []models.VendorHoursSpan{},
+		Overrides: []models.VendorHoursOverride{},
+	}
+	for _, span := range schedule.Weekly {
+		result.Weekly = append(result.Weekly, models.VendorHoursSpan{
+			Weekday:  int(span.Weekday),
+			OpensAt:  span.Opens.String(),
+			ClosesAt: span.Closes.String(),
+		})
+	}
+
+	now := time.Now()
+	result.IsOpenNow = schedule.IsOpenAt(now)
+	result.NextOpenAt = schedule.NextOpenAt(now)
+
+	overridesQuery := `
+		SELECT id, to_char(starts_on, 'YYYY-MM-DD'), to_char(ends_on, 'YYYY-MM-DD'), is_closed,
+			to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'), COALESCE(note, ''), created_at
+		FROM vendor_hour_overrides
+		WHERE vendor_id = $1 AND ends_on >= $2::date
+		ORDER BY starts_on ASC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, overridesQuery, vendorID, hours.Date(now).Format(hours.DateLayout))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get vendor hours overrides")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var override models.VendorHoursOverride
+		err := rows.Scan(
+			&override.ID,
+			&override.StartsOn,
+			&override.EndsOn,
+			&override.IsClosed,
+			&override.OpensAt,
+			&override.ClosesAt,
+			&override.Note,
+			&override.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor hours override")
+			return nil, err
+		}
+		result.Overrides = append(result.Overrides, override)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendor hours overrides")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *hoursRepository) ReplaceWeeklyHours(ctx context.Context, vendorID uuid.UUID, spans []hours.Span) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin hours transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE waakye_vendors SET updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to touch vendor for hours")
+		return err
+	}
+	if err := expectAffected(result, ErrVendorNotFound); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM vendor_hours WHERE vendor_id = $1`, vendorID); err != nil {
+		log.Error().Err(err).Msg("Failed to clear vendor hours")
+		return err
+	}
+
+	for _, span := range spans {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO vendor_hours (vendor_id, weekday, opens_at, closes_at) VALUES ($1, $2, $3::time, $4::time)`,
+			vendorID, int(span.Weekday), span.Opens.String(), span.Closes.String(),
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to insert vendor hours")
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit vendor hours")
+		return err
+	}
+
+	return nil
+}
+
+func (r *hoursRepository) AddOverride(ctx context.Context, vendorID, createdBy uuid.UUID, override hours.Override, note string) (*models.VendorHoursOverride, error) {
+	var opensAt, closesAt *string
+	if !override.Closed {
+		opens, closes := override.Opens.String(), override.Closes.String()
+		opensAt, closesAt = &opens, &closes
+	}
+
+	query := `
+		INSERT INTO vendor_hour_overrides (vendor_id, starts_on, ends_on, is_closed, opens_at, closes_at, note, created_by)
+		SELECT id, $2::date, $3::date, $4, $5::time, $6::time, NULLIF($7, ''), $8
+		FROM waakye_vendors
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, created_at
+	`
+
+	created := &models.VendorHoursOverride{
+		StartsOn: override.StartsOn.Format(hours.DateLayout),
+		EndsOn:   override.EndsOn.Format(hours.DateLayout),
+		IsClosed: override.Closed,
+		OpensAt:  opensAt,
+		ClosesAt: closesAt,
+		Note:     note,
+	}
+	err := r.db.QueryRowContext(ctx, query,
+		vendorID, created.StartsOn, created.EndsOn, override.Closed, opensAt, closesAt, note, createdBy,
+	).Scan(&created.ID, &created.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVendorNotFound
+		}
+		log.Error().Err(err).Msg("Failed to add vendor hours override")
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *hoursRepository) DeleteOverride(ctx context.Context, vendorID, overrideID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM vendor_hour_overrides WHERE id = $1 AND vendor_id = $2`, overrideID, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete vendor hours override")
+		return err
+	}
+
+	return expectAffected(result, ErrHoursOverrideNotFound)
+}
+
+// LoadSchedules reads the weekly hours and the overrides still in effect for each vendor.
+// Vendors without structured hours are missing from the map.
+func (r *hoursRepository) LoadSchedules(ctx context.Context, vendorIDs []uuid.UUID) (map[uuid.UUID]hours.Schedule, error) {
+	schedules := map[uuid.UUID]hours.Schedule{}
+	if len(vendorIDs) == 0 {
+		return schedules, nil
+	}
+
+	ids := make([]string, 0, len(vendorIDs))
+	for _, id := range vendorIDs {
+		ids = append(ids, id.String())
+	}
+
+	weeklyQuery := `
+		SELECT vendor_id, weekday, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI')
+		FROM vendor_hours
+		WHERE vendor_id = ANY($1::uuid[])
+		ORDER BY vendor_id, weekday, opens_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, weeklyQuery, ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load vendor hours")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var vendorID uuid.UUID
+		var weekday int
+		var opensAt, closesAt string
+		if err := rows.Scan(&vendorID, &weekday, &opensAt, &closesAt); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor hours")
+			return nil, err
+		}
+
+		span, err := toSpan(weekday, opensAt, closesAt)
+		if err != nil {
+			return nil, err
+		}
+
+		schedule := schedules[vendorID]
+		schedule.Weekly = append(schedule.Weekly, span)
+		schedules[vendorID] = schedule
+	}
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendor hours")
+		return nil, err
+	}
+
+	// Yesterday's overrides still decide whether an overnight span is running; the newest wins
+	overridesQuery := `
+		SELECT vendor_id, to_char(starts_on, 'YYYY-MM-DD'), to_char(ends_on, 'YYYY-MM-DD'), is_closed,
+			COALESCE(to_char(opens_at, 'HH24:MI'), ''), COALESCE(to_char(closes_at, 'HH24:MI'), '')
+		FROM vendor_hour_overrides
+		WHERE vendor_id = ANY($1::uuid[]) AND ends_on >= $2::date - 1
+		ORDER BY vendor_id, created_at DESC
+	`
+
+	overrideRows, err := r.db.QueryContext(ctx, overridesQuery, ids, hours.Date(time.Now()).Format(hours.DateLayout))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load vendor hours overrides")
+		return nil, err
+	}
+	defer overrideRows.Close()
+
+	for overrideRows.Next() {
+		var vendorID uuid.UUID
+		var startsOn, endsOn, opensAt, closesAt string
+		var override hours.Override
+		if err := overrideRows.Scan(&vendorID, &startsOn, &endsOn, &override.Closed, &opensAt, &closesAt); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor hours override")
+			return nil, err
+		}
+
+		if override.StartsOn, err = time.ParseInLocation(hours.DateLayout, startsOn, hours.Accra); err != nil {
+			return nil, err
+		}
+		if override.EndsOn, err = time.ParseInLocation(hours.DateLayout, endsOn, hours.Accra); err != nil {
+			return nil, err
+		}
+		if !override.Closed {
+			if override.Opens, err = hours.ParseClock(opensAt); err != nil {
+				return nil, err
+			}
+			if override.Closes, err = hours.ParseClock(closesAt); err != nil {
+				return nil, err
+			}
+		}
+
+		schedule := schedules[vendorID]
+		schedule.Overrides = append(schedule.Overrides, override)
+		schedules[vendorID] = schedule
+	}
+	if err := overrideRows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendor hours overrides")
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// ListVendorsWithoutHours returns the free text hours of live vendors with no structured hours yet
+func (r *hoursRepository) ListVendorsWithoutHours(ctx context.Context) (map[uuid.UUID]string, error) {
+	query := `
+		SELECT wv.id, wv.operating_hours
+		FROM waakye_vendors wv
+		WHERE wv.deleted_at IS NULL
+			AND COALESCE(wv.operating_hours, '') <> ''
+			AND NOT EXISTS (SELECT 1 FROM vendor_hours h WHERE h.vendor_id = wv.id)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list vendors without hours")
+		return nil, err
+	}
+	defer rows.Close()
+
+	vendors := map[uuid.UUID]string{}
+	for rows.Next() {
+		var id uuid.UUID
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor hours text")
+			return nil, err
+		}
+		vendors[id] = text
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendors without hours")
+		return nil, err
+	}
+
+	return vendors, nil
+}
+
+// attachOpenStatus fills in is_open_now and next_open_at for vendors that have structured hours
+func (r *hoursRepository) attachOpenStatus(ctx context.Context, vendors []models.WaakyeVendor) error {
+	ids := make([]uuid.UUID, 0, len(vendors))
+	for _, vendor := range vendors {
+		ids = append(ids, vendor.ID)
+	}
+
+	schedules, err := r.LoadSchedules(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range vendors {
+		schedule, ok := schedules[vendors[i].ID]
+		if !ok || schedule.IsEmpty() {
+			continue
+		}
+		open := schedule.IsOpenAt(now)
+		vendors[i].IsOpenNow = &open
+		vendors[i].NextOpenAt = schedule.NextOpenAt(now)
+	}
+
+	return nil
+}
+
+func toSpan(weekday int, opensAt, closesAt string) (hours.Span, error) {
+	opens, err := hours.ParseClock(opensAt)
+	if err != nil {
+		return hours.Span{}, fmt.Errorf("opens_at %q: %w", opensAt, err)
+	}
+	closes, err := hours.ParseClock(closesAt)
+	if err != nil {
+		return hours.Span{}, fmt.Errorf("closes_at %q: %w", closesAt, err)
+	}
+	return hours.Span{Weekday: time.Weekday(weekday), Opens: opens, Closes: closes}, nil
+}
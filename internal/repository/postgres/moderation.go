@@ -0,0 +1,413 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidReviewTransition is returned when a review cannot be moved to the requested state
+var ErrInvalidReviewTransition = errors.New("invalid review moderation transition")
+
+// ErrAlreadyReported is returned when a user reports the same review twice
+var ErrAlreadyReported = errors.New("review already reported by this user")
+
+type ModerationRepository interface {
+	ReportReview(ctx context.Context, vendorID, ratingID, reporterID uuid.UUID, request *models.ReportReviewRequest) error
+	FlagReview(ctx context.Context, ratingID uuid.UUID, notes string) error
+	GetReviewModeration(ctx context.Context, ratingID uuid.UUID) (*models.ReviewModeration, error)
+	TransitionReview(ctx context.Context, ratingID, actorID uuid.UUID, to models.ReviewStatus, notes string) error
+	DeleteReview(ctx context.Context, ratingID, actorID uuid.UUID, notes string) error
+	ListModerationQueue(ctx context.Context, status models.ReviewStatus, page, pageSize int) ([]models.ModerationQueueItem, error)
+	CountModerationQueue(ctx context.Context, status models.ReviewStatus) (int64, error)
+}
+
+type moderationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) ModerationRepository {
+	return &moderationRepository{
+		db: db,
+	}
+}
+
+// ReportReview records a user's report of a review and puts published reviews in the moderation
+// queue. Hidden reviews cannot be reported.
+func (r *moderationRepository) ReportReview(ctx context.Context, vendorID, ratingID, reporterID uuid.UUID, request *models.ReportReviewRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin report transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	ratingVendorID, current, err := lockReviewStatus(ctx, tx, ratingID)
+	if err != nil {
+		return err
+	}
+	if ratingVendorID != vendorID || current == models.ReviewHidden {
+		return ErrRatingNotFound
+	}
+
+	reportQuery := `
+		INSERT INTO review_reports (rating_id, reporter_id, reason, details)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, reportQuery, ratingID, reporterID, request.Reason, request.Details); err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyReported
+		}
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to store review report")
+		return err
+	}
+
+	if err := setReviewStatus(ctx, tx, ratingID, &reporterID, models.ModerationReported, current, models.ReviewFlagged, request.Reason); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit review report")
+		return err
+	}
+
+	return nil
+}
+
+// FlagReview puts a published review in the moderation queue on behalf of the automatic checks.
+// Reviews that are already flagged or hidden keep their state but the flag is still logged.
+func (r *moderationRepository) FlagReview(ctx context.Context, ratingID uuid.UUID, notes string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin flag transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	_, current, err := lockReviewStatus(ctx, tx, ratingID)
+	if err != nil {
+		return err
+	}
+
+	to := current
+	if current == models.ReviewPublished {
+		to = models.ReviewFlagged
+	}
+
+	if err := setReviewStatus(ctx, tx, ratingID, nil, models.ModerationFlagged, current, to, notes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit review flag")
+		return err
+	}
+
+	return nil
+}
+
+func (r *moderationRepository) GetReviewModeration(ctx context.Context, ratingID uuid.UUID) (*models.ReviewModeration, error) {
+	reviewQuery := fmt.Sprintf(`
+		SELECT %s, vr.moderation_status
+		%s
+		WHERE vr.id = $1 AND vr.deleted_at IS NULL
+	`, reviewColumns, reviewFrom)
+
+	moderation := models.ReviewModeration{
+		Reports: []models.ReviewReport{},
+		Log:     []models.ModerationLogEntry{},
+	}
+	err := scanReview(r.db.QueryRowContext(ctx, reviewQuery, ratingID), &moderation.Review, &moderation.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRatingNotFound
+		}
+		log.Error().Err(err).Msg("Failed to get review moderation")
+		return nil, err
+	}
+
+	reportsQuery := `
+		SELECT id, rating_id, reporter_id, reason, COALESCE(details, ''), created_at, resolved_at
+		FROM review_reports
+		WHERE rating_id = $1
+		ORDER BY created_at DESC
+	`
+
+	reportRows, err := r.db.QueryContext(ctx, reportsQuery, ratingID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get review reports")
+		return nil, err
+	}
+	defer reportRows.Close()
+
+	for reportRows.Next() {
+		var report models.ReviewReport
+		err := reportRows.Scan(
+			&report.ID,
+			&report.RatingID,
+			&report.ReporterID,
+			&report.Reason,
+			&report.Details,
+			&report.CreatedAt,
+			&report.ResolvedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan review report")
+			return nil, err
+		}
+		moderation.Reports = append(moderation.Reports, report)
+	}
+	if err := reportRows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over review reports")
+		return nil, err
+	}
+
+	logQuery := `
+		SELECT id, rating_id, actor_id, action, from_status, to_status, COALESCE(notes, ''), created_at
+		FROM review_moderation_log
+		WHERE rating_id = $1
+		ORDER BY created_at DESC
+	`
+
+	logRows, err := r.db.QueryContext(ctx, logQuery, ratingID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get review moderation log")
+		return nil, err
+	}
+	defer logRows.Close()
+
+	for logRows.Next() {
+		var entry models.ModerationLogEntry
+		err := logRows.Scan(
+			&entry.ID,
+			&entry.RatingID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.FromStatus,
+			&entry.ToStatus,
+			&entry.Notes,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan review moderation log")
+			return nil, err
+		}
+		moderation.Log = append(moderation.Log, entry)
+	}
+	if err := logRows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over review moderation log")
+		return nil, err
+	}
+
+	return &moderation, nil
+}
+
+// TransitionReview hides or restores a review and resolves its open reports. Hiding a review takes
+// it out of its vendor's stats and restoring it adds it back.
+func (r *moderationRepository) TransitionReview(ctx context.Context, ratingID, actorID uuid.UUID, to models.ReviewStatus, notes string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin moderation transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	vendorID, current, err := lockReviewStatus(ctx, tx, ratingID)
+	if err != nil {
+		return err
+	}
+
+	if !current.CanTransitionTo(to) {
+		return ErrInvalidReviewTransition
+	}
+
+	action := models.ModerationRestored
+	if to == models.ReviewHidden {
+		action = models.ModerationHidden
+		if err := adjustRatingStats(ctx, tx, vendorID, ratingID, -1); err != nil {
+			return err
+		}
+	}
+
+	if err := setReviewStatus(ctx, tx, ratingID, &actorID, action, current, to, notes); err != nil {
+		return err
+	}
+
+	if current == models.ReviewHidden {
+		if err := adjustRatingStats(ctx, tx, vendorID, ratingID, 1); err != nil {
+			return err
+		}
+	}
+
+	if err := resolveReviewReports(ctx, tx, ratingID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit moderation transition")
+		return err
+	}
+
+	return nil
+}
+
+// DeleteReview deletes a review the way its author would, keeping the reason in the moderation log
+func (r *moderationRepository) DeleteReview(ctx context.Context, ratingID, actorID uuid.UUID, notes string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin moderation transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	vendorID, current, err := lockReviewStatus(ctx, tx, ratingID)
+	if err != nil {
+		return err
+	}
+
+	if err := setReviewStatus(ctx, tx, ratingID, &actorID, models.ModerationDeleted, current, current, notes); err != nil {
+		return err
+	}
+
+	if err := resolveReviewReports(ctx, tx, ratingID); err != nil {
+		return err
+	}
+
+	if err := deleteRating(ctx, tx, vendorID, ratingID, actorID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit review deletion")
+		return err
+	}
+
+	return nil
+}
+
+// ListModerationQueue lists reviews in a moderation state, the ones waiting longest first
+func (r *moderationRepository) ListModerationQueue(ctx context.Context, status models.ReviewStatus, page, pageSize int) ([]models.ModerationQueueItem, error) {
+	query := fmt.Sprintf(`
+		SELECT %s, vr.moderation_status,
+			(SELECT COUNT(*) FROM review_reports rr WHERE rr.rating_id = vr.id AND rr.resolved_at IS NULL) AS open_reports,
+			(
+				SELECT COALESCE(json_agg(DISTINCT rr.reason), '[]')
+				FROM review_reports rr
+				WHERE rr.rating_id = vr.id AND rr.resolved_at IS NULL
+			) AS reasons,
+			(SELECT MAX(l.created_at) FROM review_moderation_log l WHERE l.rating_id = vr.id) AS last_changed_at
+		%s
+		WHERE vr.moderation_status = $1 AND vr.deleted_at IS NULL
+		ORDER BY last_changed_at ASC NULLS FIRST, vr.created_at ASC
+		LIMIT $2 OFFSET $3
+	`, reviewColumns, reviewFrom)
+
+	offset := (page - 1) * pageSize
+	rows, err := r.db.QueryContext(ctx, query, status, pageSize, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list moderation queue")
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue := []models.ModerationQueueItem{}
+	for rows.Next() {
+		var item models.ModerationQueueItem
+		var reasons []byte
+		if err := scanReview(rows, &item.Review, &item.Status, &item.OpenReports, &reasons, &item.LastChangedAt); err != nil {
+			log.Error().Err(err).Msg("Failed to scan moderation queue item")
+			return nil, err
+		}
+		if err := json.Unmarshal(reasons, &item.Reasons); err != nil {
+			log.Error().Err(err).Msg("Failed to decode report reasons")
+			return nil, err
+		}
+		queue = append(queue, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over moderation queue")
+		return nil, err
+	}
+
+	return queue, nil
+}
+
+func (r *moderationRepository) CountModerationQueue(ctx context.Context, status models.ReviewStatus) (int64, error) {
+	query := `SELECT COUNT(*) FROM vendor_ratings WHERE moderation_status = $1 AND deleted_at IS NULL`
+
+	var totalItems int64
+	if err := r.db.QueryRowContext(ctx, query, status).Scan(&totalItems); err != nil {
+		log.Error().Err(err).Msg("Failed to count moderation queue")
+		return 0, err
+	}
+
+	return totalItems, nil
+}
+
+// lockReviewStatus reads an active review's vendor and moderation state and locks the review until
+// the transaction ends
+func lockReviewStatus(ctx context.Context, tx *sql.Tx, ratingID uuid.UUID) (uuid.UUID, models.ReviewStatus, error) {
+	query := `
+		SELECT vendor_id, moderation_status
+		FROM vendor_ratings
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	var vendorID uuid.UUID
+	var status models.ReviewStatus
+	if err := tx.QueryRowContext(ctx, query, ratingID).Scan(&vendorID, &status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, "", ErrRatingNotFound
+		}
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to lock review")
+		return uuid.Nil, "", err
+	}
+
+	return vendorID, status, nil
+}
+
+// setReviewStatus moves a review to a new moderation state and records the action in its log.
+// A nil actor is the automatic wordlist check.
+func setReviewStatus(ctx context.Context, tx *sql.Tx, ratingID uuid.UUID, actorID *uuid.UUID, action string, from, to models.ReviewStatus, notes string) error {
+	if from != to {
+		updateQuery := `UPDATE vendor_ratings SET moderation_status = $2 WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, updateQuery, ratingID, to); err != nil {
+			log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to update review status")
+			return err
+		}
+	}
+
+	logQuery := `
+		INSERT INTO review_moderation_log (rating_id, actor_id, action, from_status, to_status, notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.ExecContext(ctx, logQuery, ratingID, actorID, action, from, to, notes); err != nil {
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to record moderation log")
+		return err
+	}
+
+	return nil
+}
+
+// resolveReviewReports closes a review's open reports once a moderator has acted on it
+func resolveReviewReports(ctx context.Context, tx *sql.Tx, ratingID uuid.UUID) error {
+	query := `
+		UPDATE review_reports
+		SET resolved_at = CURRENT_TIMESTAMP
+		WHERE rating_id = $1 AND resolved_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, query, ratingID); err != nil {
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to resolve review reports")
+		return err
+	}
+
+	return nil
+}
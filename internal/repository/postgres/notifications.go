@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrNotificationNotFound is returned when a notification does not exist or belongs to another user
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type NotificationRepository interface {
+	ListNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) ([]models.Notification, error)
+	CountNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool) (int64, error)
+	MarkNotificationRead(ctx context.Context, userID, notificationID uuid.UUID) error
+	MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+func (r *notificationRepository) ListNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) ([]models.Notification, error) {
+	query := `
+		SELECT id, type, message, vendor_id, rating_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	offset := (page - 1) * pageSize
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, pageSize, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list notifications")
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		err := rows.Scan(
+			&notification.ID,
+			&notification.Type,
+			&notification.Message,
+			&notification.VendorID,
+			&notification.RatingID,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan notification")
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over notifications")
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool) (int64, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)`
+
+	var totalItems int64
+	if err := r.db.QueryRowContext(ctx, query, userID, unreadOnly).Scan(&totalItems); err != nil {
+		log.Error().Err(err).Msg("Failed to count notifications")
+		return 0, err
+	}
+
+	return totalItems, nil
+}
+
+func (r *notificationRepository) MarkNotificationRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, notificationID, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to mark notification read")
+		return err
+	}
+
+	return expectAffected(result, ErrNotificationNotFound)
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user as read and returns how many
+// there were
+func (r *notificationRepository) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to mark notifications read")
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// createNotification adds a notification for a user inside the transaction that caused it
+func createNotification(ctx context.Context, tx *sql.Tx, userID uuid.UUID, notificationType, message string, vendorID, ratingID *uuid.UUID) error {
+	query := `
+		INSERT INTO notifications (user_id, type, message, vendor_id, rating_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, notificationType, message, vendorID, ratingID); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to create notification")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/google/uuid"
+)
+
+// ErrCursorSortMismatch is returned when a cursor issued for one sort is used with another
+var ErrCursorSortMismatch = errors.New("cursor does not match the requested sort")
+
+// queryBuilder collects WHERE conditions and their arguments, numbering placeholders as it goes.
+// Conditions are written with "?" placeholders so callers never build SQL out of user input.
+type queryBuilder struct {
+	args       []interface{}
+	conditions []string
+}
+
+// arg registers a value and returns its positional placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// where adds a condition, replacing each "?" with the placeholder of the matching value
+func (b *queryBuilder) where(condition string, values ...interface{}) {
+	var clause strings.Builder
+	next := 0
+	for _, char := range condition {
+		if char == '?' && next < len(values) {
+			clause.WriteString(b.arg(values[next]))
+			next++
+			continue
+		}
+		clause.WriteRune(char)
+	}
+	b.conditions = append(b.conditions, clause.String())
+}
+
+// whereSQL renders the collected conditions joined with AND
+func (b *queryBuilder) whereSQL() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// sortSpec is an ORDER BY expression with its direction.
+// cast is the SQL type a cursor's text sort key is converted back to when paging by keyset.
+type sortSpec struct {
+	expr string
+	cast string
+	desc bool
+}
+
+// orderSQL orders by the sort expression with the vendor ID as a tie breaker in the same direction
+func (s sortSpec) orderSQL() string {
+	return s.keysetOrderSQL("wv.id", false)
+}
+
+// keysetOrderSQL orders by the sort expression and idColumn, flipping the direction for backward pages
+func (s sortSpec) keysetOrderSQL(idColumn string, backward bool) string {
+	direction := "ASC"
+	if s.desc != backward {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s %s, %s %s", s.expr, direction, idColumn, direction)
+}
+
+// decimalPattern matches the numbers Postgres prints for numeric and float8 values, leaving out
+// the hexadecimal, infinite and NaN forms strconv would also accept
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// timestampLayouts are the forms Postgres prints a timestamptz as text in, with the fractional
+// seconds it leaves out when they are zero
+var timestampLayouts = []string{"2006-01-02 15:04:05-07", "2006-01-02 15:04:05-07:00"}
+
+// checkCursorValue makes sure a cursor's sort key can be cast to the sort's type. Cursors come
+// back from clients, so a tampered one would otherwise fail the cast in Postgres.
+func (s sortSpec) checkCursorValue(value string) error {
+	valid := false
+	switch s.cast {
+	case "integer":
+		_, err := strconv.ParseInt(value, 10, 32)
+		valid = err == nil
+	case "bigint":
+		_, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil
+	case "numeric", "float8":
+		_, err := strconv.ParseFloat(value, 64)
+		valid = err == nil && decimalPattern.MatchString(value)
+	case "timestamptz":
+		for _, layout := range timestampLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				valid = true
+				break
+			}
+		}
+	default:
+		// Postgres text can hold anything but NUL
+		valid = !strings.ContainsRune(value, 0)
+	}
+
+	if !valid {
+		return utils.ErrInvalidCursor
+	}
+	return nil
+}
+
+// whereAfter restricts a query to the rows past the cursor in the direction it pages. It returns
+// utils.ErrInvalidCursor when the cursor's sort key does not fit the sort.
+func (b *queryBuilder) whereAfter(s sortSpec, idColumn string, cursor *models.PageCursor) error {
+	if err := s.checkCursorValue(cursor.Value); err != nil {
+		return err
+	}
+
+	op := ">"
+	if s.desc != cursor.Backward {
+		op = "<"
+	}
+	b.where(fmt.Sprintf("(%s, %s) %s (?::%s, ?)", s.expr, idColumn, op, s.cast), cursor.Value, cursor.ID)
+	return nil
+}
+
+// keysetRow is a scanned row with the text form of its sort key, used to build cursors
+type keysetRow[T any] struct {
+	item T
+	key  string
+	id   uuid.UUID
+}
+
+// finishKeysetPage trims the extra row fetched to detect more results, restores the natural
+// order of a backward page and builds the cursors either side of the returned rows
+func finishKeysetPage[T any](rows []keysetRow[T], pageSize int, sort string, cursor *models.PageCursor) ([]T, *models.KeysetPage) {
+	backward := cursor != nil && cursor.Backward
+
+	hasMore := len(rows) > pageSize
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	items := make([]T, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, row.item)
+	}
+
+	page := &models.KeysetPage{}
+	if len(rows) == 0 {
+		return items, page
+	}
+
+	first, last := rows[0], rows[len(rows)-1]
+	// Anything fetched from a cursor has rows before it, except a backward page that ran out
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		page.Prev = &models.PageCursor{Sort: sort, Value: first.key, ID: first.id, Backward: true}
+	}
+	if backward || hasMore {
+		page.Next = &models.PageCursor{Sort: sort, Value: last.key, ID: last.id}
+	}
+
+	return items, page
+}
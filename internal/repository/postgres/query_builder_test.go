@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/aglili/waakye-directory/internal/utils"
+	"github.com/google/uuid"
+)
+
+// keysetRows builds rows named by their sort keys, in the order a query returned them
+func keysetRows(keys ...string) []keysetRow[string] {
+	rows := make([]keysetRow[string], 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, keysetRow[string]{item: key, key: key, id: keyID(key)})
+	}
+	return rows
+}
+
+// keyID is the ID keysetRows gives the row with key
+func keyID(key string) uuid.UUID {
+	return uuid.NewSHA1(uuid.Nil, []byte(key))
+}
+
+func TestFinishKeysetPage(t *testing.T) {
+	forward := &models.PageCursor{Sort: "name", Value: "b", ID: keyID("b")}
+	backward := &models.PageCursor{Sort: "name", Value: "f", ID: keyID("f"), Backward: true}
+
+	tests := []struct {
+		name   string
+		rows   []keysetRow[string]
+		cursor *models.PageCursor
+		items  []string
+		prev   *models.PageCursor
+		next   *models.PageCursor
+	}{
+		{
+			name:  "first page with more after it",
+			rows:  keysetRows("a", "b", "c"),
+			items: []string{"a", "b"},
+			next:  &models.PageCursor{Sort: "name", Value: "b", ID: keyID("b")},
+		},
+		{
+			name:  "only page",
+			rows:  keysetRows("a", "b"),
+			items: []string{"a", "b"},
+		},
+		{
+			name:   "forward page in the middle",
+			rows:   keysetRows("c", "d", "e"),
+			cursor: forward,
+			items:  []string{"c", "d"},
+			prev:   &models.PageCursor{Sort: "name", Value: "c", ID: keyID("c"), Backward: true},
+			next:   &models.PageCursor{Sort: "name", Value: "d", ID: keyID("d")},
+		},
+		{
+			name:   "last page",
+			rows:   keysetRows("c"),
+			cursor: forward,
+			items:  []string{"c"},
+			prev:   &models.PageCursor{Sort: "name", Value: "c", ID: keyID("c"), Backward: true},
+		},
+		{
+			name:   "empty page past the end",
+			cursor: forward,
+			items:  []string{},
+		},
+		{
+			name:   "backward page in the middle",
+			rows:   keysetRows("e", "d", "c"),
+			cursor: backward,
+			items:  []string{"d", "e"},
+			prev:   &models.PageCursor{Sort: "name", Value: "d", ID: keyID("d"), Backward: true},
+			next:   &models.PageCursor{Sort: "name", Value: "e", ID: keyID("e")},
+		},
+		{
+			name:   "backward page reaching the start",
+			rows:   keysetRows("b", "a"),
+			cursor: backward,
+			items:  []string{"a", "b"},
+			next:   &models.PageCursor{Sort: "name", Value: "b", ID: keyID("b")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, page := finishKeysetPage(tt.rows, 2, "name", tt.cursor)
+			if !reflect.DeepEqual(items, tt.items) {
+				t.Errorf("items = %v, want %v", items, tt.items)
+			}
+			if !reflect.DeepEqual(page.Prev, tt.prev) {
+				t.Errorf("prev = %+v, want %+v", page.Prev, tt.prev)
+			}
+			if !reflect.DeepEqual(page.Next, tt.next) {
+				t.Errorf("next = %+v, want %+v", page.Next, tt.next)
+			}
+		})
+	}
+}
+
+func TestWhereAfter(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name     string
+		spec     sortSpec
+		backward bool
+		want     string
+	}{
+		{"ascending forward", sortSpec{expr: "wv.name", cast: "text"}, false, "(wv.name, wv.id) > ($1::text, $2)"},
+		{"ascending backward", sortSpec{expr: "wv.name", cast: "text"}, true, "(wv.name, wv.id) < ($1::text, $2)"},
+		{"descending forward", sortSpec{expr: "wv.created_at", cast: "timestamptz", desc: true}, false, "(wv.created_at, wv.id) < ($1::timestamptz, $2)"},
+		{"descending backward", sortSpec{expr: "wv.created_at", cast: "timestamptz", desc: true}, true, "(wv.created_at, wv.id) > ($1::timestamptz, $2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := "Auntie Muni"
+			if tt.spec.cast == "timestamptz" {
+				value = "2024-05-01 12:30:00.5+00"
+			}
+
+			builder := &queryBuilder{}
+			err := builder.whereAfter(tt.spec, "wv.id", &models.PageCursor{Value: value, ID: id, Backward: tt.backward})
+			if err != nil {
+				t.Fatalf("whereAfter: %v", err)
+			}
+			if got := builder.whereSQL(); got != "WHERE "+tt.want {
+				t.Errorf("whereSQL = %q, want %q", got, "WHERE "+tt.want)
+			}
+			if want := []interface{}{value, id}; !reflect.DeepEqual(builder.args, want) {
+				t.Errorf("args = %v, want %v", builder.args, want)
+			}
+		})
+	}
+}
+
+func TestCheckCursorValue(t *testing.T) {
+	tests := []struct {
+		cast  string
+		value string
+		valid bool
+	}{
+		{"integer", "5", true},
+		{"integer", "-3", true},
+		{"integer", "abc", false},
+		{"integer", "4.5", false},
+		{"integer", "3000000000", false},
+		{"bigint", "3000000000", true},
+		{"bigint", "", false},
+		{"numeric", "4.5000000000000000", true},
+		{"numeric", "0", true},
+		{"numeric", "abc", false},
+		{"numeric", "NaN", false},
+		{"numeric", "0x1p-2", false},
+		{"float8", "1.234", true},
+		{"float8", "1e-05", true},
+		{"float8", "Infinity", false},
+		{"float8", "1e400", false},
+		{"timestamptz", "2024-05-01 12:30:00.123456+00", true},
+		{"timestamptz", "2024-05-01 12:30:00+00", true},
+		{"timestamptz", "2024-05-01 12:30:00.5+05:30", true},
+		{"timestamptz", "abc", false},
+		{"timestamptz", "2024-05-01", false},
+		{"text", "Auntie Muni's", true},
+		{"text", "", true},
+		{"text", "bad\x00name", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cast+" "+tt.value, func(t *testing.T) {
+			err := sortSpec{cast: tt.cast}.checkCursorValue(tt.value)
+			if tt.valid && err != nil {
+				t.Errorf("checkCursorValue(%q) = %v, want nil", tt.value, err)
+			}
+			if !tt.valid && !errors.Is(err, utils.ErrInvalidCursor) {
+				t.Errorf("checkCursorValue(%q) = %v, want ErrInvalidCursor", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestWhereAfterRejectsInvalidCursor(t *testing.T) {
+	builder := &queryBuilder{}
+	spec := sortSpec{expr: "COALESCE(rs.avg_rating, 0)", cast: "numeric", desc: true}
+	err := builder.whereAfter(spec, "wv.id", &models.PageCursor{Sort: "rating", Value: "abc", ID: uuid.New()})
+	if !errors.Is(err, utils.ErrInvalidCursor) {
+		t.Fatalf("whereAfter error = %v, want ErrInvalidCursor", err)
+	}
+	if len(builder.conditions) != 0 || len(builder.args) != 0 {
+		t.Errorf("an invalid cursor added a condition: %v %v", builder.conditions, builder.args)
+	}
+}
@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aglili/waakye-directory/internal/hours"
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ratingStatsDimensions are the rating columns vendor_rating_stats keeps a sum and histogram of
+var ratingStatsDimensions = []string{"hygiene", "value", "taste", "service"}
+
+// histogramColumns selects a vendor_rating_stats row's histograms as JSON, in the order
+// histogramScanner reads them
+const histogramColumns = `
+	to_json(hygiene_histogram), to_json(value_histogram), to_json(taste_histogram), to_json(service_histogram)`
+
+// histogramScanner reads histogramColumns into a set of star histograms
+type histogramScanner struct {
+	histograms *models.RatingHistograms
+	raw        [4][]byte
+}
+
+// dest returns the scan destinations for histogramColumns
+func (s *histogramScanner) dest() []interface{} {
+	return []interface{}{&s.raw[0], &s.raw[1], &s.raw[2], &s.raw[3]}
+}
+
+// decode fills the histograms once the row has been scanned
+func (s *histogramScanner) decode() error {
+	targets := []*models.StarHistogram{
+		&s.histograms.Hygiene, &s.histograms.Value, &s.histograms.Taste, &s.histograms.Service,
+	}
+	for i, target := range targets {
+		if err := json.Unmarshal(s.raw[i], target); err != nil {
+			return fmt.Errorf("decode %s histogram: %w", ratingStatsDimensions[i], err)
+		}
+	}
+	return nil
+}
+
+// adjustRatingStats adds a rating's current values to its vendor's stats, or takes them away when
+// delta is -1. Callers take a rating away before changing, deleting or hiding it and add it back
+// after an edit or a restore, inside the same transaction as the write, so the stats never drift
+// from the ratings. Hidden ratings are not counted, so adjusting one does nothing.
+func adjustRatingStats(ctx context.Context, tx *sql.Tx, vendorID, ratingID uuid.UUID, delta int) error {
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO vendor_rating_stats (vendor_id) VALUES ($1) ON CONFLICT (vendor_id) DO NOTHING`,
+		vendorID,
+	); err != nil {
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to create rating stats")
+		return err
+	}
+
+	assignments := []string{"rating_count = s.rating_count + $3"}
+	for _, dimension := range ratingStatsDimensions {
+		assignments = append(assignments,
+			fmt.Sprintf("%[1]s_sum = s.%[1]s_sum + $3 * vr.%[1]s_rating", dimension),
+			fmt.Sprintf("%[1]s_histogram[vr.%[1]s_rating] = s.%[1]s_histogram[vr.%[1]s_rating] + $3", dimension),
+		)
+	}
+
+	// A removed rating may have been the latest, so last_rated_at is looked up again from the rest
+	query := fmt.Sprintf(`
+		UPDATE vendor_rating_stats s
+		SET %s,
+			last_rated_at = CASE
+				WHEN $3 > 0 THEN GREATEST(s.last_rated_at, COALESCE(vr.updated_at, vr.created_at))
+				ELSE (
+					SELECT MAX(COALESCE(other.updated_at, other.created_at))
+					FROM vendor_ratings other
+					WHERE other.vendor_id = s.vendor_id AND other.deleted_at IS NULL
+						AND other.moderation_status <> 'hidden' AND other.id <> vr.id
+				)
+			END,
+			updated_at = CURRENT_TIMESTAMP
+		FROM vendor_ratings vr
+		WHERE s.vendor_id = $1 AND vr.id = $2 AND vr.moderation_status <> 'hidden'
+	`, strings.Join(assignments, ",\n\t\t\t"))
+
+	if _, err := tx.ExecContext(ctx, query, vendorID, ratingID, delta); err != nil {
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to update rating stats")
+		return err
+	}
+
+	return nil
+}
+
+// RepairRatingStats rebuilds every vendor's rating stats from its active, visible ratings and
+// returns how many vendors have stats
+func (r *ratingsRepository) RepairRatingStats(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin rating stats repair")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Block rating writes until the rebuilt stats are committed so none are lost in between
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE vendor_ratings IN SHARE MODE`); err != nil {
+		log.Error().Err(err).Msg("Failed to lock ratings for stats repair")
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM vendor_rating_stats`); err != nil {
+		log.Error().Err(err).Msg("Failed to clear rating stats")
+		return 0, err
+	}
+
+	columns := []string{"rating_count"}
+	aggregates := []string{"COUNT(*)"}
+	for _, dimension := range ratingStatsDimensions {
+		columns = append(columns, dimension+"_sum", dimension+"_histogram")
+
+		stars := make([]string, 0, 5)
+		for star := 1; star <= 5; star++ {
+			stars = append(stars, fmt.Sprintf("COUNT(*) FILTER (WHERE %s_rating = %d)", dimension, star))
+		}
+		aggregates = append(aggregates,
+			fmt.Sprintf("SUM(%s_rating)", dimension),
+			fmt.Sprintf("ARRAY[%s]::INTEGER[]", strings.Join(stars, ", ")),
+		)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO vendor_rating_stats (vendor_id, %s, last_rated_at)
+		SELECT vendor_id, %s, MAX(COALESCE(updated_at, created_at))
+		FROM vendor_ratings
+		WHERE deleted_at IS NULL AND moderation_status <> 'hidden'
+		GROUP BY vendor_id
+	`, strings.Join(columns, ", "), strings.Join(aggregates, ", "))
+
+	result, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to rebuild rating stats")
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit rating stats repair")
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetRatingStats returns a vendor's star histograms and the averages of the ratings given in every
+// week or month between two dates. Buckets without ratings are included so the trend has no gaps.
+// It returns ErrVendorNotFound when the vendor does not exist or has been deleted.
+func (r *ratingsRepository) GetRatingStats(ctx context.Context, vendorID uuid.UUID, params models.RatingTrendParams) (*models.VendorRatingStats, error) {
+	if !params.Bucket.Valid() {
+		return nil, fmt.Errorf("unknown rating trend bucket %q", params.Bucket)
+	}
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM waakye_vendors WHERE id = $1 AND deleted_at IS NULL)`, vendorID,
+	).Scan(&exists)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check vendor for rating stats")
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrVendorNotFound
+	}
+
+	stats := &models.VendorRatingStats{
+		VendorID: vendorID,
+		Bucket:   params.Bucket,
+		From:     params.From.Format(hours.DateLayout),
+		To:       params.To.Format(hours.DateLayout),
+		Trend:    []models.RatingTrendPoint{},
+	}
+
+	totalsQuery := fmt.Sprintf(`
+		SELECT rating_count, last_rated_at, %s
+		FROM vendor_rating_stats
+		WHERE vendor_id = $1
+	`, histogramColumns)
+
+	histograms := histogramScanner{histograms: &stats.Histograms}
+	err = r.db.QueryRowContext(ctx, totalsQuery, vendorID).Scan(
+		append([]interface{}{&stats.TotalRatings, &stats.LastRatedAt}, histograms.dest()...)...,
+	)
+	if err == nil {
+		err = histograms.decode()
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Msg("Failed to get vendor rating stats")
+		return nil, err
+	}
+
+	// Buckets are laid out in Accra wall time from $3 up to the day after $4, and each one only
+	// counts the ratings inside the requested range
+	trendQuery := `
+		SELECT
+			b.starts_at AT TIME ZONE 'Africa/Accra',
+			COUNT(vr.id),
+			AVG((vr.hygiene_rating + vr.value_rating + vr.taste_rating + vr.service_rating) / 4.0),
+			AVG(vr.hygiene_rating),
+			AVG(vr.value_rating),
+			AVG(vr.taste_rating),
+			AVG(vr.service_rating)
+		FROM generate_series(
+			date_trunc($2, $3::date::timestamp),
+			$4::date::timestamp,
+			('1 ' || $2)::interval
+		) AS b(starts_at)
+		LEFT JOIN vendor_ratings vr
+			ON vr.vendor_id = $1
+			AND vr.deleted_at IS NULL
+			AND vr.moderation_status <> 'hidden'
+			AND vr.created_at >= GREATEST(b.starts_at, $3::date::timestamp) AT TIME ZONE 'Africa/Accra'
+			AND vr.created_at < LEAST(b.starts_at + ('1 ' || $2)::interval, $4::date::timestamp + interval '1 day') AT TIME ZONE 'Africa/Accra'
+		GROUP BY b.starts_at
+		ORDER BY b.starts_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, trendQuery, vendorID, string(params.Bucket), stats.From, stats.To)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get vendor rating trend")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var point models.RatingTrendPoint
+		err := rows.Scan(
+			&point.Start,
+			&point.RatingCount,
+			&point.OverallRating,
+			&point.HygieneRating,
+			&point.ValueRating,
+			&point.TasteRating,
+			&point.ServiceRating,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan rating trend point")
+			return nil, err
+		}
+		stats.Trend = append(stats.Trend, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over rating trend")
+		return nil, err
+	}
+
+	return stats, nil
+}
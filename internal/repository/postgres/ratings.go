@@ -11,9 +11,23 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrRatingNotFound is returned when a rating does not exist for the vendor or has been deleted
+var ErrRatingNotFound = errors.New("rating not found")
+
 type RatingsRepository interface {
-	RateVendor(ctx context.Context,vendorID uuid.UUID ,request *models.RateVendorRequest) error
+	RateVendor(ctx context.Context, vendorID, userID uuid.UUID, request *models.RateVendorRequest) (*models.Review, bool, error)
+	GetRating(ctx context.Context, vendorID, ratingID uuid.UUID) (*models.Review, error)
+	UpdateRating(ctx context.Context, vendorID, ratingID, editorID uuid.UUID, request *models.RateVendorRequest) (*models.Review, error)
+	DeleteRating(ctx context.Context, vendorID, ratingID, editorID uuid.UUID) error
+	ListRatingRevisions(ctx context.Context, vendorID, ratingID uuid.UUID) ([]models.RatingRevision, error)
+	RepairRatingStats(ctx context.Context) (int64, error)
+	RepairHelpfulCounts(ctx context.Context) (int64, error)
 	GetVendorGeneralRatings(ctx context.Context, vendorID uuid.UUID) (*models.VendorRatings, error)
+	GetRatingStats(ctx context.Context, vendorID uuid.UUID, params models.RatingTrendParams) (*models.VendorRatingStats, error)
+	ListVendorReviews(ctx context.Context, vendorID uuid.UUID, params models.ReviewListParams) ([]models.Review, error)
+	ListVendorReviewsAfter(ctx context.Context, vendorID uuid.UUID, params models.ReviewListParams) ([]models.Review, *models.KeysetPage, error)
+	CountVendorReviews(ctx context.Context, vendorID uuid.UUID, filter models.ReviewFilter) (int64, error)
+	SetHelpfulVote(ctx context.Context, vendorID, ratingID, userID uuid.UUID, helpful bool) (*models.HelpfulVote, error)
 }
 
 type ratingsRepository struct {
@@ -26,92 +40,334 @@ func NewRatingRepository(db *sql.DB) RatingsRepository {
 	}
 }
 
-func (r *ratingsRepository) RateVendor(ctx context.Context,vendorID uuid.UUID ,request *models.RateVendorRequest) error {
-	query := `
-		INSERT INTO vendor_ratings (vendor_id, hygiene_rating, value_rating, taste_rating, service_rating, comment)
-		VALUES ($1, $2, $3, $4, $5, $6)
+// RateVendor records a user's rating of a vendor. Each user has one active rating per vendor, so
+// rating again updates it; the returned flag reports whether a new rating was created.
+// It returns ErrVendorNotFound when the vendor does not exist or has been deleted.
+func (r *ratingsRepository) RateVendor(ctx context.Context, vendorID, userID uuid.UUID, request *models.RateVendorRequest) (*models.Review, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin rating transaction")
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// The vendor stays locked so it cannot be deleted while the rating is written
+	vendorQuery := `SELECT 1 FROM waakye_vendors WHERE id = $1 AND deleted_at IS NULL FOR SHARE`
+	if err := tx.QueryRowContext(ctx, vendorQuery, vendorID).Scan(new(int)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, ErrVendorNotFound
+		}
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to lock vendor for rating")
+		return nil, false, fmt.Errorf("rate vendor: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO vendor_ratings (vendor_id, user_id, hygiene_rating, value_rating, taste_rating, service_rating, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (vendor_id, user_id) WHERE deleted_at IS NULL AND user_id IS NOT NULL DO NOTHING
+		RETURNING id
 	`
-	_, err := r.db.ExecContext(
+
+	var ratingID uuid.UUID
+	created := true
+	err = tx.QueryRowContext(
 		ctx,
-		query,
+		insertQuery,
 		vendorID,
-		request.HygeineRating,
+		userID,
+		request.HygieneRating,
 		request.ValueRating,
 		request.TasteRating,
 		request.ServiceRating,
 		request.Comment,
-	)
+	).Scan(&ratingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The user already rated this vendor, so the new rating replaces it
+		created = false
+		err = tx.QueryRowContext(ctx,
+			`SELECT id FROM vendor_ratings WHERE vendor_id = $1 AND user_id = $2 AND deleted_at IS NULL`,
+			vendorID, userID,
+		).Scan(&ratingID)
+		if err == nil {
+			err = updateRating(ctx, tx, vendorID, ratingID, userID, request)
+		}
+	} else if err == nil {
+		err = adjustRatingStats(ctx, tx, vendorID, ratingID, 1)
+		if err == nil && request.PhotoIDs != nil {
+			err = setReviewPhotos(ctx, tx, ratingID, *request.PhotoIDs)
+		}
+	}
+	if errors.Is(err, ErrInvalidReviewPhotos) {
+		return nil, false, err
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("failed to rate vendor")
-		return errors.New("failed to rate vendor")
+		return nil, false, fmt.Errorf("rate vendor: %w", err)
+	}
+
+	rating, err := getRating(ctx, tx, vendorID, ratingID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit rating")
+		return nil, false, err
+	}
+
+	return rating, created, nil
+}
+
+func (r *ratingsRepository) GetRating(ctx context.Context, vendorID, ratingID uuid.UUID) (*models.Review, error) {
+	return getRating(ctx, r.db, vendorID, ratingID)
+}
+
+func (r *ratingsRepository) UpdateRating(ctx context.Context, vendorID, ratingID, editorID uuid.UUID, request *models.RateVendorRequest) (*models.Review, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin rating transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := updateRating(ctx, tx, vendorID, ratingID, editorID, request); err != nil {
+		return nil, err
+	}
+
+	rating, err := getRating(ctx, tx, vendorID, ratingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit rating update")
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+func (r *ratingsRepository) DeleteRating(ctx context.Context, vendorID, ratingID, editorID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin rating transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteRating(ctx, tx, vendorID, ratingID, editorID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit rating deletion")
+		return err
 	}
 
 	return nil
 }
 
-func (r *ratingsRepository) GetVendorGeneralRatings(ctx context.Context, vendorID uuid.UUID) (*models.VendorRatings, error) {
-	ratingsQuery := `
-		SELECT
-			COALESCE(AVG(hygiene_rating), 0) AS hygiene_rating,
-			COALESCE(AVG(value_rating), 0) AS value_rating,
-			COALESCE(AVG(taste_rating), 0) AS taste_rating,
-			COALESCE(AVG(service_rating), 0) AS service_rating,
-			COALESCE(AVG((hygiene_rating + value_rating + taste_rating + service_rating) / 4), 0) AS overall_rating,
-			COUNT(*) AS total_ratings
-		FROM vendor_ratings
-		WHERE vendor_id = $1
+func (r *ratingsRepository) ListRatingRevisions(ctx context.Context, vendorID, ratingID uuid.UUID) ([]models.RatingRevision, error) {
+	query := `
+		SELECT rv.id, rv.rating_id, rv.editor_id, rv.action, rv.hygiene_rating, rv.value_rating,
+			rv.taste_rating, rv.service_rating, COALESCE(rv.comment, ''), rv.created_at
+		FROM vendor_rating_revisions rv
+		INNER JOIN vendor_ratings vr ON vr.id = rv.rating_id
+		WHERE rv.rating_id = $1 AND vr.vendor_id = $2
+		ORDER BY rv.created_at DESC
 	`
 
-	var ratings models.VendorRatings
-	err := r.db.QueryRowContext(ctx, ratingsQuery, vendorID).Scan(
-		&ratings.HygieneRating,
-		&ratings.ValueRating,
-		&ratings.TasteRating,
-		&ratings.ServiceRating,
-		&ratings.OverallRating,
-		&ratings.TotalRatings,
-	)
+	rows, err := r.db.QueryContext(ctx, query, ratingID, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list rating revisions")
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.RatingRevision{}
+	for rows.Next() {
+		var revision models.RatingRevision
+		err := rows.Scan(
+			&revision.ID,
+			&revision.RatingID,
+			&revision.EditorID,
+			&revision.Action,
+			&revision.HygieneRating,
+			&revision.ValueRating,
+			&revision.TasteRating,
+			&revision.ServiceRating,
+			&revision.Comment,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan rating revision")
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over rating revisions")
+		return nil, err
+	}
 
+	return revisions, nil
+}
+
+// queryRower is implemented by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// getRating loads an active rating of the vendor
+func getRating(ctx context.Context, db queryRower, vendorID, ratingID uuid.UUID) (*models.Review, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		WHERE vr.id = $1 AND vr.vendor_id = $2 AND vr.deleted_at IS NULL
+	`, reviewColumns, reviewFrom)
+
+	var rating models.Review
+	if err := scanReview(db.QueryRowContext(ctx, query, ratingID, vendorID), &rating); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRatingNotFound
+		}
+		log.Error().Err(err).Msg("Failed to get rating")
+		return nil, err
+	}
+
+	return &rating, nil
+}
+
+// updateRating snapshots an active rating into its history and then overwrites it, and its photos
+// when the request has any, moving the vendor's stats from the old values to the new ones
+func updateRating(ctx context.Context, tx *sql.Tx, vendorID, ratingID, editorID uuid.UUID, request *models.RateVendorRequest) error {
+	if err := recordRatingRevision(ctx, tx, vendorID, ratingID, editorID, models.RatingRevisionUpdated); err != nil {
+		return err
+	}
+
+	if err := adjustRatingStats(ctx, tx, vendorID, ratingID, -1); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE vendor_ratings
+		SET hygiene_rating = $3, value_rating = $4, taste_rating = $5, service_rating = $6,
+			comment = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND vendor_id = $2
+	`
+
+	_, err := tx.ExecContext(ctx, query, ratingID, vendorID,
+		request.HygieneRating, request.ValueRating, request.TasteRating, request.ServiceRating, request.Comment)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to update rating")
+		return err
+	}
+
+	if request.PhotoIDs != nil {
+		if err := setReviewPhotos(ctx, tx, ratingID, *request.PhotoIDs); err != nil {
+			return err
+		}
+	}
+
+	return adjustRatingStats(ctx, tx, vendorID, ratingID, 1)
+}
+
+// deleteRating snapshots an active rating into its history, takes it out of the vendor's stats and
+// soft deletes it
+func deleteRating(ctx context.Context, tx *sql.Tx, vendorID, ratingID, editorID uuid.UUID) error {
+	if err := recordRatingRevision(ctx, tx, vendorID, ratingID, editorID, models.RatingRevisionDeleted); err != nil {
+		return err
+	}
+
+	if err := adjustRatingStats(ctx, tx, vendorID, ratingID, -1); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE vendor_ratings SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND vendor_id = $2`,
+		ratingID, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete rating")
+		return err
+	}
+
+	return nil
+}
+
+// recordRatingRevision copies the current values of an active rating into its history, locking
+// the rating for the rest of the transaction
+func recordRatingRevision(ctx context.Context, tx *sql.Tx, vendorID, ratingID, editorID uuid.UUID, action string) error {
+	var locked uuid.UUID
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM vendor_ratings WHERE id = $1 AND vendor_id = $2 AND deleted_at IS NULL FOR UPDATE`,
+		ratingID, vendorID,
+	).Scan(&locked)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return &models.VendorRatings{}, nil
+			return ErrRatingNotFound
 		}
-		log.Error().Err(err).Msg("Failed to get vendor ratings")
-		return nil, fmt.Errorf("failed to get vendor ratings: %w", err)
+		log.Error().Err(err).Msg("Failed to lock rating")
+		return err
 	}
 
-	commentsQuery := `
-		SELECT comment, created_at
+	query := `
+		INSERT INTO vendor_rating_revisions
+			(rating_id, editor_id, action, hygiene_rating, value_rating, taste_rating, service_rating, comment)
+		SELECT id, $2, $3, hygiene_rating, value_rating, taste_rating, service_rating, comment
 		FROM vendor_ratings
-		WHERE vendor_id = $1 AND comment != ''
-		ORDER BY created_at DESC
+		WHERE id = $1
 	`
 
-	rows, err := r.db.QueryContext(ctx, commentsQuery, vendorID)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get vendor comments")
-		return nil, fmt.Errorf("failed to get vendor comments: %w", err)
+	if _, err := tx.ExecContext(ctx, query, ratingID, editorID, action); err != nil {
+		log.Error().Err(err).Msg("Failed to record rating revision")
+		return err
 	}
-	defer rows.Close()
 
-	// Initialize empty comments slice
-	ratings.Comments = []models.VendorComment{}
+	return nil
+}
 
-	// Iterate through the result rows
-	for rows.Next() {
-		var comment models.VendorComment
-		if err := rows.Scan(&comment.Comment, &comment.CreatedAt); err != nil {
-			log.Error().Err(err).Msg("Failed to scan vendor comment")
-			return nil, fmt.Errorf("failed to scan vendor comment: %w", err)
+func (r *ratingsRepository) GetVendorGeneralRatings(ctx context.Context, vendorID uuid.UUID) (*models.VendorRatings, error) {
+	ratingsQuery := `
+		SELECT
+			COALESCE(hygiene_sum / NULLIF(rating_count::numeric, 0), 0) AS hygiene_rating,
+			COALESCE(value_sum / NULLIF(rating_count::numeric, 0), 0) AS value_rating,
+			COALESCE(taste_sum / NULLIF(rating_count::numeric, 0), 0) AS taste_rating,
+			COALESCE(service_sum / NULLIF(rating_count::numeric, 0), 0) AS service_rating,
+			COALESCE((hygiene_sum + value_sum + taste_sum + service_sum) / NULLIF(4.0 * rating_count, 0), 0) AS overall_rating,
+			rating_count AS total_ratings,
+			%s
+		FROM vendor_rating_stats
+		WHERE vendor_id = $1
+	`
+
+	var ratings models.VendorRatings
+	histograms := histogramScanner{histograms: &ratings.Histograms}
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf(ratingsQuery, histogramColumns), vendorID).Scan(
+		append([]interface{}{
+			&ratings.HygieneRating,
+			&ratings.ValueRating,
+			&ratings.TasteRating,
+			&ratings.ServiceRating,
+			&ratings.OverallRating,
+			&ratings.TotalRatings,
+		}, histograms.dest()...)...,
+	)
+	if err == nil {
+		err = histograms.decode()
+	}
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.VendorRatings{LatestReviews: []models.Review{}}, nil
 		}
-		ratings.Comments = append(ratings.Comments, comment)
+		log.Error().Err(err).Msg("Failed to get vendor ratings")
+		return nil, fmt.Errorf("failed to get vendor ratings: %w", err)
 	}
 
-	// Check for errors from iterating over rows
-	if err := rows.Err(); err != nil {
-		log.Error().Err(err).Msg("Error iterating over vendor comments")
-		return nil, fmt.Errorf("error iterating over vendor comments: %w", err)
+	if ratings.LatestReviews, err = listLatestReviews(ctx, r.db, vendorID); err != nil {
+		return nil, err
 	}
 
 	return &ratings, nil
-}
\ No newline at end of file
+}
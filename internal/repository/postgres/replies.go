@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrReplyExists is returned when a review already has a reply
+var ErrReplyExists = errors.New("review already has a reply")
+
+// ErrReplyNotFound is returned when a review has no reply
+var ErrReplyNotFound = errors.New("reply not found")
+
+type ReviewReplyRepository interface {
+	CreateReply(ctx context.Context, vendorID, ratingID, authorID uuid.UUID, body string) (*models.Review, error)
+	UpdateReply(ctx context.Context, vendorID, ratingID uuid.UUID, body string) (*models.Review, error)
+	DeleteReply(ctx context.Context, vendorID, ratingID uuid.UUID) error
+}
+
+type reviewReplyRepository struct {
+	db *sql.DB
+}
+
+func NewReviewReplyRepository(db *sql.DB) ReviewReplyRepository {
+	return &reviewReplyRepository{
+		db: db,
+	}
+}
+
+// CreateReply posts the vendor's reply to a visible review and lets the reviewer know
+func (r *reviewReplyRepository) CreateReply(ctx context.Context, vendorID, ratingID, authorID uuid.UUID, body string) (*models.Review, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin reply transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reviewQuery := fmt.Sprintf(`
+		SELECT vr.user_id, wv.name
+		FROM vendor_ratings vr
+		INNER JOIN waakye_vendors wv ON wv.id = vr.vendor_id
+		WHERE vr.id = $1 AND vr.vendor_id = $2 AND %s
+		FOR UPDATE OF vr
+	`, reviewVisible)
+
+	var reviewerID *uuid.UUID
+	var vendorName string
+	if err := tx.QueryRowContext(ctx, reviewQuery, ratingID, vendorID).Scan(&reviewerID, &vendorName); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRatingNotFound
+		}
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to lock review for reply")
+		return nil, err
+	}
+
+	insertQuery := `
+		INSERT INTO review_replies (rating_id, vendor_id, author_id, body)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, insertQuery, ratingID, vendorID, authorID, body); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrReplyExists
+		}
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to create reply")
+		return nil, err
+	}
+
+	// Reviews left before ratings were tied to accounts have nobody to notify
+	if reviewerID != nil && *reviewerID != authorID {
+		message := fmt.Sprintf("%s replied to your review", vendorName)
+		if err := createNotification(ctx, tx, *reviewerID, models.NotificationReviewReply, message, &vendorID, &ratingID); err != nil {
+			return nil, err
+		}
+	}
+
+	review, err := getRating(ctx, tx, vendorID, ratingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit reply")
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (r *reviewReplyRepository) UpdateReply(ctx context.Context, vendorID, ratingID uuid.UUID, body string) (*models.Review, error) {
+	query := `
+		UPDATE review_replies
+		SET body = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE rating_id = $1 AND vendor_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, ratingID, vendorID, body)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to update reply")
+		return nil, err
+	}
+	if err := expectAffected(result, ErrReplyNotFound); err != nil {
+		return nil, err
+	}
+
+	return getRating(ctx, r.db, vendorID, ratingID)
+}
+
+func (r *reviewReplyRepository) DeleteReply(ctx context.Context, vendorID, ratingID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM review_replies WHERE rating_id = $1 AND vendor_id = $2`,
+		ratingID, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete reply")
+		return err
+	}
+
+	return expectAffected(result, ErrReplyNotFound)
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidReviewPhotos is returned when a review is given photos that do not exist, are not
+// images or were uploaded by someone other than the reviewer
+var ErrInvalidReviewPhotos = errors.New("review photos must be images uploaded by the reviewer")
+
+// galleryPhotoCount is how many review photos a vendor's gallery shows
+const galleryPhotoCount = 24
+
+// reviewPhotosColumn selects a review's photos as a JSON array, in the order they were attached
+const reviewPhotosColumn = `
+	(
+		SELECT COALESCE(json_agg(json_build_object('id', up.id, 'key', up.file_name, 'variants', up.variants) ORDER BY rph.position), '[]')
+		FROM review_photos rph
+		INNER JOIN uploads up ON up.id = rph.upload_id
+		WHERE rph.rating_id = vr.id
+	)`
+
+// storedReviewPhoto is one element of reviewPhotosColumn
+type storedReviewPhoto struct {
+	ID       uuid.UUID            `json:"id"`
+	Key      string               `json:"key"`
+	Variants models.ImageVariants `json:"variants"`
+}
+
+// decodeReviewPhotos reads reviewPhotosColumn. The photos' URLs are filled in when responding.
+func decodeReviewPhotos(data []byte) ([]models.ReviewPhoto, error) {
+	var stored []storedReviewPhoto
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("decode review photos: %w", err)
+	}
+
+	photos := make([]models.ReviewPhoto, 0, len(stored))
+	for _, photo := range stored {
+		photos = append(photos, models.ReviewPhoto{ID: photo.ID, Key: photo.Key, Variants: photo.Variants})
+	}
+	return photos, nil
+}
+
+// setReviewPhotos replaces a review's photos. Every photo has to be an image uploaded by the
+// review's author, otherwise nothing is attached and ErrInvalidReviewPhotos is returned.
+func setReviewPhotos(ctx context.Context, tx *sql.Tx, ratingID uuid.UUID, photoIDs []uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_photos WHERE rating_id = $1`, ratingID); err != nil {
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to clear review photos")
+		return err
+	}
+
+	if len(photoIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(photoIDs))
+	for _, id := range photoIDs {
+		ids = append(ids, id.String())
+	}
+
+	query := `
+		INSERT INTO review_photos (rating_id, upload_id, position)
+		SELECT vr.id, up.id, p.position
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS p(upload_id, position)
+		INNER JOIN uploads up ON up.id = p.upload_id
+		INNER JOIN vendor_ratings vr ON vr.id = $1
+		WHERE up.owner_id = vr.user_id AND up.content_type LIKE 'image/%'
+	`
+
+	result, err := tx.ExecContext(ctx, query, ratingID, ids)
+	if err != nil {
+		log.Error().Err(err).Str("rating_id", ratingID.String()).Msg("Failed to attach review photos")
+		return err
+	}
+
+	attached, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if attached != int64(len(photoIDs)) {
+		return ErrInvalidReviewPhotos
+	}
+
+	return nil
+}
+
+// listVendorGallery returns the vendor's own image followed by the newest photos from its visible
+// reviews
+func listVendorGallery(ctx context.Context, db *sql.DB, vendor *models.WaakyeVendor) ([]models.VendorPhoto, error) {
+	gallery := []models.VendorPhoto{}
+	if vendor.ImageKey != "" || vendor.ImageURL != "" {
+		gallery = append(gallery, models.VendorPhoto{
+			Key:      vendor.ImageKey,
+			URL:      vendor.ImageURL,
+			Variants: vendor.ImageVariants,
+			Source:   models.VendorPhotoSourceVendor,
+		})
+	}
+
+	query := fmt.Sprintf(`
+		SELECT up.file_name, up.variants, vr.id, rph.created_at
+		FROM review_photos rph
+		INNER JOIN uploads up ON up.id = rph.upload_id
+		INNER JOIN vendor_ratings vr ON vr.id = rph.rating_id
+		WHERE vr.vendor_id = $1 AND %s
+		ORDER BY rph.created_at DESC, vr.id, rph.position
+		LIMIT $2
+	`, reviewVisible)
+
+	rows, err := db.QueryContext(ctx, query, vendor.ID, galleryPhotoCount)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list vendor gallery")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		photo := models.VendorPhoto{Source: models.VendorPhotoSourceReview}
+		var variants []byte
+		if err := rows.Scan(&photo.Key, &variants, &photo.RatingID, &photo.CreatedAt); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor gallery photo")
+			return nil, err
+		}
+		if err := json.Unmarshal(variants, &photo.Variants); err != nil {
+			return nil, fmt.Errorf("decode gallery photo variants: %w", err)
+		}
+		gallery = append(gallery, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendor gallery")
+		return nil, err
+	}
+
+	return gallery, nil
+}
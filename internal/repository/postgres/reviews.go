@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// latestReviewCount is how many reviews are embedded in vendor and rating summary responses
+const latestReviewCount = 3
+
+// reviewColumns are the columns selected for a review, in the order scanReview reads them
+const reviewColumns = `
+	vr.id, vr.vendor_id, u.id, u.display_name,
+	vr.hygiene_rating, vr.value_rating, vr.taste_rating, vr.service_rating,
+	COALESCE(vr.comment, ''), vr.helpful_count, vr.created_at, COALESCE(vr.updated_at, vr.created_at),
+	rp.id, rp.body, rp.created_at, rp.updated_at,` + reviewPhotosColumn
+
+// reviewFrom joins a rating with its author and the vendor owner's reply
+const reviewFrom = `
+	FROM vendor_ratings vr
+	LEFT JOIN users u ON u.id = vr.user_id
+	LEFT JOIN review_replies rp ON rp.rating_id = vr.id`
+
+// reviewVisible keeps the ratings shown to the public and counted in a vendor's stats: not deleted
+// and not hidden by a moderator
+const reviewVisible = "vr.deleted_at IS NULL AND vr.moderation_status <> 'hidden'"
+
+// reviewStars is the total of a rating's four dimensions, which orders reviews by their overall score
+const reviewStars = "(vr.hygiene_rating + vr.value_rating + vr.taste_rating + vr.service_rating)"
+
+// scanReview reads reviewColumns into review, followed by any extra columns the query selected
+func scanReview(scanner rowScanner, review *models.Review, extra ...interface{}) error {
+	var authorID *uuid.UUID
+	var authorName *string
+	var replyID *uuid.UUID
+	var replyBody *string
+	var replyCreatedAt, replyUpdatedAt *time.Time
+	var photos []byte
+	dest := []interface{}{
+		&review.ID,
+		&review.VendorID,
+		&authorID,
+		&authorName,
+		&review.Scores.Hygiene,
+		&review.Scores.Value,
+		&review.Scores.Taste,
+		&review.Scores.Service,
+		&review.Comment,
+		&review.HelpfulCount,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&replyID,
+		&replyBody,
+		&replyCreatedAt,
+		&replyUpdatedAt,
+		&photos,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	var err error
+	if review.Photos, err = decodeReviewPhotos(photos); err != nil {
+		return err
+	}
+
+	if authorID != nil && authorName != nil {
+		review.Author = &models.ReviewAuthor{ID: *authorID, DisplayName: *authorName}
+	}
+	if replyID != nil {
+		review.Reply = &models.ReviewReply{
+			ID:        *replyID,
+			Body:      *replyBody,
+			CreatedAt: *replyCreatedAt,
+			UpdatedAt: *replyUpdatedAt,
+		}
+	}
+	scores := review.Scores
+	review.Scores.Overall = float64(scores.Hygiene+scores.Value+scores.Taste+scores.Service) / 4
+	return nil
+}
+
+// reviewSortSpec maps a requested review sort onto its ORDER BY expression
+func reviewSortSpec(sort models.ReviewSort) (sortSpec, error) {
+	switch sort {
+	case "", models.ReviewSortNewest:
+		return sortSpec{expr: "vr.created_at", cast: "timestamptz", desc: true}, nil
+	case models.ReviewSortHighest:
+		return sortSpec{expr: reviewStars, cast: "integer", desc: true}, nil
+	case models.ReviewSortLowest:
+		return sortSpec{expr: reviewStars, cast: "integer", desc: false}, nil
+	case models.ReviewSortMostHelpful:
+		return sortSpec{expr: "vr.helpful_count", cast: "integer", desc: true}, nil
+	}
+
+	return sortSpec{}, fmt.Errorf("%w: %q", ErrUnknownSort, sort)
+}
+
+// applyReviewFilters adds the conditions shared by a review listing and its count
+func applyReviewFilters(builder *queryBuilder, vendorID uuid.UUID, filter models.ReviewFilter) {
+	builder.where("vr.vendor_id = ?", vendorID)
+	builder.where(reviewVisible)
+
+	if filter.MinStars != nil {
+		builder.where(reviewStars+" >= ?", *filter.MinStars*4)
+	}
+	if filter.HasComment != nil {
+		if *filter.HasComment {
+			builder.where("COALESCE(vr.comment, '') <> ''")
+		} else {
+			builder.where("COALESCE(vr.comment, '') = ''")
+		}
+	}
+}
+
+func (r *ratingsRepository) ListVendorReviews(ctx context.Context, vendorID uuid.UUID, params models.ReviewListParams) ([]models.Review, error) {
+	order, err := reviewSortSpec(params.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &queryBuilder{}
+	applyReviewFilters(builder, vendorID, params.Filter)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		%s
+		%s
+		LIMIT %s OFFSET %s
+	`, reviewColumns, reviewFrom, builder.whereSQL(), order.keysetOrderSQL("vr.id", false),
+		builder.arg(params.PageSize), builder.arg((params.Page-1)*params.PageSize))
+
+	return queryReviews(ctx, r.db, query, builder.args...)
+}
+
+// ListVendorReviewsAfter is the keyset paginated form of ListVendorReviews, seeking past the
+// cursor's sort key and id instead of skipping rows
+func (r *ratingsRepository) ListVendorReviewsAfter(ctx context.Context, vendorID uuid.UUID, params models.ReviewListParams) ([]models.Review, *models.KeysetPage, error) {
+	if params.Sort == "" {
+		params.Sort = models.ReviewSortNewest
+	}
+	if params.Cursor != nil && params.Cursor.Sort != string(params.Sort) {
+		return nil, nil, ErrCursorSortMismatch
+	}
+
+	order, err := reviewSortSpec(params.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	builder := &queryBuilder{}
+	applyReviewFilters(builder, vendorID, params.Filter)
+
+	backward := false
+	if params.Cursor != nil {
+		if err := builder.whereAfter(order, "vr.id", params.Cursor); err != nil {
+			return nil, nil, err
+		}
+		backward = params.Cursor.Backward
+	}
+
+	// One extra row tells us whether there is another page
+	query := fmt.Sprintf(`
+		SELECT %s, (%s)::text AS sort_key
+		%s
+		%s
+		%s
+		LIMIT %s
+	`, reviewColumns, order.expr, reviewFrom, builder.whereSQL(),
+		order.keysetOrderSQL("vr.id", backward), builder.arg(params.PageSize+1))
+
+	rows, err := r.db.QueryContext(ctx, query, builder.args...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list vendor reviews")
+		return nil, nil, fmt.Errorf("failed to list vendor reviews: %w", err)
+	}
+	defer rows.Close()
+
+	page := []keysetRow[models.Review]{}
+	for rows.Next() {
+		var row keysetRow[models.Review]
+		if err := scanReview(rows, &row.item, &row.key); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor review")
+			return nil, nil, fmt.Errorf("failed to scan vendor review: %w", err)
+		}
+		row.id = row.item.ID
+		page = append(page, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendor reviews")
+		return nil, nil, fmt.Errorf("error iterating over vendor reviews: %w", err)
+	}
+
+	reviews, keyset := finishKeysetPage(page, params.PageSize, string(params.Sort), params.Cursor)
+	return reviews, keyset, nil
+}
+
+func (r *ratingsRepository) CountVendorReviews(ctx context.Context, vendorID uuid.UUID, filter models.ReviewFilter) (int64, error) {
+	builder := &queryBuilder{}
+	applyReviewFilters(builder, vendorID, filter)
+
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM vendor_ratings vr %s`, builder.whereSQL())
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, query, builder.args...).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed to count vendor reviews")
+		return 0, fmt.Errorf("failed to count vendor reviews: %w", err)
+	}
+
+	return total, nil
+}
+
+// listLatestReviews returns a vendor's newest reviews for embedding in summary responses
+func listLatestReviews(ctx context.Context, db *sql.DB, vendorID uuid.UUID) ([]models.Review, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		WHERE vr.vendor_id = $1 AND %s
+		ORDER BY vr.created_at DESC, vr.id DESC
+		LIMIT $2
+	`, reviewColumns, reviewFrom, reviewVisible)
+
+	return queryReviews(ctx, db, query, vendorID, latestReviewCount)
+}
+
+// queryReviews runs a query selecting reviewColumns
+func queryReviews(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]models.Review, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query vendor reviews")
+		return nil, fmt.Errorf("failed to query vendor reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		var review models.Review
+		if err := scanReview(rows, &review); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor review")
+			return nil, fmt.Errorf("failed to scan vendor review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendor reviews")
+		return nil, fmt.Errorf("error iterating over vendor reviews: %w", err)
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Search documents. These expressions must match the expression indexes from the search migration.
+const (
+	vendorSearchDocument   = `to_tsvector('simple', COALESCE(wv.name, '') || ' ' || COALESCE(wv.description, ''))`
+	locationSearchDocument = `to_tsvector('simple', COALESCE(l.landmark, '') || ' ' || l.street_address || ' ' || l.city)`
+)
+
+// searchTerms matches any word of the query rather than all of them, so filler words like
+// "near" in "near Accra Mall" do not rule out a vendor whose landmark is Accra Mall
+const searchTerms = `
+	search AS (
+		SELECT replace(plainto_tsquery('simple', $1)::text, ' & ', ' | ')::tsquery AS query
+	)
+`
+
+// searchMatch selects vendors matched by full text or by trigram word similarity on the short fields
+const searchMatch = `
+	wv.deleted_at IS NULL
+	AND (
+		` + vendorSearchDocument + ` @@ search.query
+		OR ` + locationSearchDocument + ` @@ search.query
+		OR $1 <% wv.name
+		OR $1 <% l.landmark
+		OR $1 <% l.street_address
+		OR $1 <% l.city
+	)
+`
+
+// proximityBoostWeight caps how much being close adds to a result's score
+const proximityBoostWeight = 0.5
+
+func (r *vendorRepository) SearchVendors(ctx context.Context, params models.VendorSearchParams) ([]models.WaakyeVendor, error) {
+	args := []interface{}{params.Query, params.PageSize, (params.Page - 1) * params.PageSize}
+
+	distance := "NULL::float8"
+	proximityBoost := "0"
+	if params.Latitude != nil && params.Longitude != nil {
+		args = append(args, *params.Latitude, *params.Longitude)
+		distance = distanceKmExpr("$4", "$5")
+		proximityBoost = fmt.Sprintf("%g / (1.0 + %s)", proximityBoostWeight, distance)
+	}
+
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT %s,
+			COALESCE(%s, 0) AS distance_km,
+			2 * (ts_rank(%s, search.query) + ts_rank(%s, search.query))
+				+ GREATEST(
+					word_similarity($1, wv.name),
+					word_similarity($1, COALESCE(l.landmark, '')),
+					word_similarity($1, l.street_address),
+					word_similarity($1, l.city)
+				)
+				+ %s AS score
+		%s
+		CROSS JOIN search
+		WHERE %s
+		ORDER BY score DESC, wv.id
+		LIMIT $2 OFFSET $3
+	`, searchTerms, vendorColumns, distance, vendorSearchDocument, locationSearchDocument, proximityBoost, vendorFrom, searchMatch)
+
+	vendors, err := r.queryVendors(ctx, query, args, withDistance, withSearchScore)
+	if err != nil {
+		log.Error().Err(err).Str("query", params.Query).Msg("Failed to search vendors")
+		return nil, err
+	}
+
+	return vendors, nil
+}
+
+func (r *vendorRepository) CountSearchVendors(ctx context.Context, params models.VendorSearchParams) (int64, error) {
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT COUNT(*)
+		FROM waakye_vendors wv
+		INNER JOIN locations l ON wv.location_id = l.id
+		CROSS JOIN search
+		WHERE %s
+	`, searchTerms, searchMatch)
+
+	var totalItems int64
+	if err := r.db.QueryRowContext(ctx, query, params.Query).Scan(&totalItems); err != nil {
+		log.Error().Err(err).Str("query", params.Query).Msg("Failed to count vendor search results")
+		return 0, err
+	}
+
+	return totalItems, nil
+}
@@ -0,0 +1,302 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type UploadRepository interface {
+	CreateUpload(ctx context.Context, upload *models.Upload, store func(upload *models.Upload) error) (duplicate bool, err error)
+	DeleteOrphanedUploads(ctx context.Context, createdBefore time.Time, limit int, deleteFiles func(objects []models.Upload) error) (int, error)
+}
+
+type uploadRepository struct {
+	db *sql.DB
+}
+
+func NewUploadRepository(db *sql.DB) UploadRepository {
+	return &uploadRepository{
+		db: db,
+	}
+}
+
+// errStoredFileRemoved is returned by recordUpload when the janitor removed the stored file the
+// upload was going to share
+var errStoredFileRemoved = errors.New("stored file was removed")
+
+// CreateUpload records an upload of the file named upload.FileName and fills in its ID and
+// creation time. When no upload references the file yet, store is called to save it and fill in
+// its details before the transaction opens, so no lock is held while it runs; later uploads share
+// the stored file, copy the details of an earlier upload and report a duplicate. A file nobody
+// referenced can't be removed by the janitor before the upload is recorded, since only uploads
+// older than the grace period are cleaned up. If a shared file is removed in the meantime, the
+// upload starts over and stores it again.
+func (r *uploadRepository) CreateUpload(ctx context.Context, upload *models.Upload, store func(upload *models.Upload) error) (bool, error) {
+	for {
+		shared, err := r.isFileReferenced(ctx, upload.FileName)
+		if err != nil {
+			return false, err
+		}
+		if !shared {
+			if err := store(upload); err != nil {
+				return false, err
+			}
+		}
+
+		duplicate, err := r.recordUpload(ctx, upload, shared)
+		if errors.Is(err, errStoredFileRemoved) {
+			continue
+		}
+		return duplicate, err
+	}
+}
+
+// isFileReferenced reports whether an upload already references the stored file
+func (r *uploadRepository) isFileReferenced(ctx context.Context, fileName string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM upload_objects WHERE file_name = $1)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, fileName).Scan(&exists); err != nil {
+		log.Error().Err(err).Str("file_name", fileName).Msg("Failed to look up stored file")
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// recordUpload references the stored file and inserts the upload. shared says whether the file
+// was found referenced, in which case the upload's details are copied from an earlier upload.
+func (r *uploadRepository) recordUpload(ctx context.Context, upload *models.Upload, shared bool) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin upload transaction")
+		return false, err
+	}
+	defer tx.Rollback()
+
+	referenceQuery := `
+		INSERT INTO upload_objects (file_name)
+		VALUES ($1)
+		ON CONFLICT (file_name) DO UPDATE SET ref_count = upload_objects.ref_count + 1
+		RETURNING ref_count
+	`
+
+	var refCount int
+	if err := tx.QueryRowContext(ctx, referenceQuery, upload.FileName).Scan(&refCount); err != nil {
+		log.Error().Err(err).Str("file_name", upload.FileName).Msg("Failed to reference stored file")
+		return false, err
+	}
+
+	if shared {
+		if refCount == 1 {
+			return false, errStoredFileRemoved
+		}
+		if err := copyUploadDetails(ctx, tx, upload); err != nil {
+			return false, err
+		}
+	}
+
+	variants, err := json.Marshal(upload.Variants)
+	if err != nil {
+		return false, err
+	}
+	if upload.Variants == nil {
+		variants = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO uploads (owner_id, file_name, original_name, content_type, size_bytes, checksum, width, height, variants)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		upload.OwnerID,
+		upload.FileName,
+		upload.OriginalName,
+		upload.ContentType,
+		upload.SizeBytes,
+		upload.Checksum,
+		upload.Width,
+		upload.Height,
+		variants,
+	).Scan(&upload.ID, &upload.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Str("file_name", upload.FileName).Msg("Failed to record upload")
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit upload")
+		return false, err
+	}
+
+	return refCount > 1, nil
+}
+
+// copyUploadDetails fills in what an earlier upload of the same file learned about it
+func copyUploadDetails(ctx context.Context, tx *sql.Tx, upload *models.Upload) error {
+	query := `
+		SELECT content_type, size_bytes, width, height, variants
+		FROM uploads
+		WHERE file_name = $1
+		LIMIT 1
+	`
+
+	var variants []byte
+	err := tx.QueryRowContext(ctx, query, upload.FileName).Scan(
+		&upload.ContentType,
+		&upload.SizeBytes,
+		&upload.Width,
+		&upload.Height,
+		&variants,
+	)
+	if err != nil {
+		log.Error().Err(err).Str("file_name", upload.FileName).Msg("Failed to look up stored file")
+		return err
+	}
+	if err := json.Unmarshal(variants, &upload.Variants); err != nil {
+		return fmt.Errorf("decode upload variants: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteOrphanedUploads removes up to limit uploads created before createdBefore that no vendor,
+// review or verification evidence uses, and returns how many it removed. deleteFiles is called
+// with the stored files no upload references any more, before anything is committed, so a file is
+// never deleted after a new upload of the same content has stored it again; if it fails, nothing
+// is removed. Rows another instance is already removing are skipped, so several can clean up at
+// once.
+func (r *uploadRepository) DeleteOrphanedUploads(ctx context.Context, createdBefore time.Time, limit int, deleteFiles func(objects []models.Upload) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin upload clean-up transaction")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM uploads
+		WHERE id IN (
+			SELECT up.id
+			FROM uploads up
+			WHERE up.created_at < $1
+				AND NOT EXISTS (SELECT 1 FROM waakye_vendors wv WHERE wv.image_upload_id = up.id)
+				AND NOT EXISTS (SELECT 1 FROM review_photos rph WHERE rph.upload_id = up.id)
+				AND NOT EXISTS (SELECT 1 FROM vendor_verification_evidence e WHERE e.upload_id = up.id)
+			ORDER BY up.created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING file_name, variants
+	`
+
+	rows, err := tx.QueryContext(ctx, query, createdBefore, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete orphaned uploads")
+		return 0, err
+	}
+	defer rows.Close()
+
+	removed := 0
+	references := map[string]int{}
+	objects := map[string]models.Upload{}
+	for rows.Next() {
+		var upload models.Upload
+		var variants []byte
+		if err := rows.Scan(&upload.FileName, &variants); err != nil {
+			log.Error().Err(err).Msg("Failed to scan orphaned upload")
+			return 0, err
+		}
+		if err := json.Unmarshal(variants, &upload.Variants); err != nil {
+			return 0, fmt.Errorf("decode upload variants: %w", err)
+		}
+		removed++
+		references[upload.FileName]++
+		objects[upload.FileName] = upload
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over orphaned uploads")
+		return 0, err
+	}
+	rows.Close()
+
+	// Files are released in name order, so two instances lock them the same way round
+	fileNames := make([]string, 0, len(references))
+	for fileName := range references {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	releaseQuery := `
+		UPDATE upload_objects
+		SET ref_count = ref_count - $2
+		WHERE file_name = $1
+		RETURNING ref_count
+	`
+
+	unreferenced := []models.Upload{}
+	for _, fileName := range fileNames {
+		var refCount int
+		if err := tx.QueryRowContext(ctx, releaseQuery, fileName, references[fileName]).Scan(&refCount); err != nil {
+			log.Error().Err(err).Str("file_name", fileName).Msg("Failed to release stored file")
+			return 0, err
+		}
+		if refCount > 0 {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM upload_objects WHERE file_name = $1`, fileName); err != nil {
+			log.Error().Err(err).Str("file_name", fileName).Msg("Failed to delete stored file record")
+			return 0, err
+		}
+		unreferenced = append(unreferenced, objects[fileName])
+	}
+
+	if len(unreferenced) > 0 {
+		if err := deleteFiles(unreferenced); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit upload clean-up")
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+// checkVendorImage makes sure uploadID is an image uploaded by ownerID, returning
+// ErrInvalidVendorImage otherwise
+func checkVendorImage(ctx context.Context, db queryRower, uploadID uuid.UUID, ownerID *uuid.UUID) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM uploads
+			WHERE id = $1 AND owner_id = $2 AND content_type LIKE 'image/%'
+		)
+	`
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, query, uploadID, ownerID).Scan(&exists); err != nil {
+		log.Error().Err(err).Str("upload_id", uploadID.String()).Msg("Failed to check vendor image")
+		return err
+	}
+	if !exists {
+		return ErrInvalidVendorImage
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUserNotFound is returned when no user matches the lookup
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrEmailTaken is returned when signing up with an email that already has an account
+var ErrEmailTaken = errors.New("email already registered")
+
+// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired or already used
+var ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+
+// Postgres error codes the repositories translate into domain errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	StoreRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) error
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{
+		db: db,
+	}
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (email, password_hash, display_name, role)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'contributor'))
+		RETURNING id, role, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, user.Email, user.PasswordHash, user.DisplayName, user.Role).
+		Scan(&user.ID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailTaken
+		}
+		log.Error().Err(err).Msg("Failed to create user")
+		return err
+	}
+
+	return nil
+}
+
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, display_name, role, created_at, updated_at
+		FROM users
+		WHERE LOWER(email) = LOWER($1)
+	`
+
+	return r.getUser(ctx, query, email)
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT id, email, password_hash, display_name, role, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	return r.getUser(ctx, query, id)
+}
+
+func (r *userRepository) getUser(ctx context.Context, query string, arg interface{}) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.DisplayName,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		log.Error().Err(err).Msg("Failed to get user")
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) StoreRefreshToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt); err != nil {
+		log.Error().Err(err).Msg("Failed to store refresh token")
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeRefreshToken revokes a valid refresh token and returns the user it belongs to.
+// Each refresh token can only be used once.
+func (r *userRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id
+	`
+
+	var userID uuid.UUID
+	if err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrRefreshTokenInvalid
+		}
+		log.Error().Err(err).Msg("Failed to consume refresh token")
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+func (r *userRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, tokenHash); err != nil {
+		log.Error().Err(err).Msg("Failed to revoke refresh token")
+		return err
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+func (r *userRepository) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	query := `
+		UPDATE users
+		SET role = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, role)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", id.String()).Msg("Failed to update user role")
+		return err
+	}
+
+	return expectAffected(result, ErrUserNotFound)
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
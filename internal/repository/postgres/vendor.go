@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/aglili/waakye-directory/internal/models"
 	"github.com/google/uuid"
@@ -12,36 +15,70 @@ import (
 
 type VendorRepository interface {
 	CreateVendor(ctx context.Context, vendor *models.WaakyeVendor) error
-	ListVendorsWithPagination(ctx context.Context, page, pageSize int) ([]models.WaakyeVendor, error)
-	CountVendors(ctx context.Context) (int64, error)
+	ListVendorsWithPagination(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, error)
+	ListVendorsAfter(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, *models.KeysetPage, error)
+	CountVendors(ctx context.Context, filter models.VendorFilter) (int64, error)
 	GetVendorByID(ctx context.Context, id uuid.UUID) (*models.WaakyeVendor, error)
-	GetNearbyVendors(ctx context.Context, latitude, longitude, radius float64) ([]models.WaakyeVendor, error)
-	GetVerifiedVendors(ctx context.Context, page, pageSize int) ([]models.WaakyeVendor, error)
-	CountVerifiedVendors(ctx context.Context) (int64, error)
-	GetTopRatedVendors(ctx context.Context) ([]models.WaakyeVendor, error)
+	GetNearbyVendors(ctx context.Context, params models.NearbyParams) ([]models.WaakyeVendor, error)
+	CountNearbyVendors(ctx context.Context, params models.NearbyParams) (int64, error)
+	GetVerifiedVendors(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, error)
+	GetVerifiedVendorsAfter(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, *models.KeysetPage, error)
+	CountVerifiedVendors(ctx context.Context, filter models.VendorFilter) (int64, error)
+	GetTopRatedVendors(ctx context.Context, params models.TopRatedParams) ([]models.WaakyeVendor, error)
+	CountTopRatedVendors(ctx context.Context, params models.TopRatedParams) (int64, error)
+	UpdateVendor(ctx context.Context, id, editorID uuid.UUID, update *models.UpdateVendorRequest) error
+	DeleteVendor(ctx context.Context, id uuid.UUID) error
+	RestoreVendor(ctx context.Context, id uuid.UUID) error
+	AssignVendorOwner(ctx context.Context, id, ownerID uuid.UUID) error
+	CountVendorsInBounds(ctx context.Context, bounds models.BoundingBox, filter models.VendorFilter) (int64, error)
+	GetVendorsInBounds(ctx context.Context, bounds models.BoundingBox, filter models.VendorFilter, limit int) ([]models.MapPoint, error)
+	ClusterVendorsInBounds(ctx context.Context, bounds models.BoundingBox, filter models.VendorFilter, cellDegrees float64) ([]models.MapPoint, error)
+	SearchVendors(ctx context.Context, params models.VendorSearchParams) ([]models.WaakyeVendor, error)
+	CountSearchVendors(ctx context.Context, params models.VendorSearchParams) (int64, error)
 }
 
+// ErrVendorNotFound is returned when a vendor does not exist or has been deleted
+var ErrVendorNotFound = errors.New("vendor not found")
+
+// ErrInvalidVendorImage is returned when a vendor's image is not an image uploaded by the user
+// creating or editing the vendor
+var ErrInvalidVendorImage = errors.New("vendor image must be an image uploaded by the editor")
+
+// ErrUnknownSort is returned when a listing is asked for a sort it does not support
+var ErrUnknownSort = errors.New("unknown sort")
+
+// ErrDistanceSortNeedsLocation is returned when sorting by distance without a lat/lng
+var ErrDistanceSortNeedsLocation = errors.New("sorting by distance requires lat and lng")
+
 type vendorRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	hours *hoursRepository
 }
 
 func NewVendorRepository(db *sql.DB) VendorRepository {
 	return &vendorRepository{
-		db: db,
+		db:    db,
+		hours: &hoursRepository{db: db},
 	}
 }
 
 func (r *vendorRepository) CreateVendor(ctx context.Context, vendor *models.WaakyeVendor) error {
+	if vendor.ImageID != nil {
+		if err := checkVendorImage(ctx, r.db, *vendor.ImageID, vendor.CreatedBy); err != nil {
+			return err
+		}
+	}
+
 	query := `
 		WITH location_insert AS (
 			INSERT INTO locations (street_address, city, region, latitude, longitude, landmark)
 			VALUES ($1, $2, $3, $4, $5, $6)
 			RETURNING id
 		)
-		INSERT INTO waakye_vendors (name, location_id, description, operating_hours,image_url, phone_number, is_verified)
-		SELECT $7, id, $8, $9, $10, $11,$12
+		INSERT INTO waakye_vendors (name, location_id, description, operating_hours, image_upload_id, image_url, phone_number, created_by, owner_id)
+		SELECT $7, id, $8, $9, $10, NULL, $11, $12, $13
 		FROM location_insert
-		RETURNING id, created_at, updated_at
+		RETURNING id, verification_status, created_at, updated_at
 	`
 
 	err := r.db.QueryRowContext(
@@ -56,10 +93,11 @@ func (r *vendorRepository) CreateVendor(ctx context.Context, vendor *models.Waak
 		vendor.Name,
 		vendor.Description,
 		vendor.OperatingHours,
-		vendor.ImageURL,
+		vendor.ImageID,
 		vendor.PhoneNumber,
-		vendor.IsVerified,
-	).Scan(&vendor.ID, &vendor.CreatedAt, &vendor.UpdatedAt)
+		vendor.CreatedBy,
+		vendor.OwnerID,
+	).Scan(&vendor.ID, &vendor.VerificationStatus, &vendor.CreatedAt, &vendor.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -73,371 +111,494 @@ func (r *vendorRepository) CreateVendor(ctx context.Context, vendor *models.Waak
 	return nil
 }
 
-func (r *vendorRepository) ListVendorsWithPagination(ctx context.Context, page, pageSize int) ([]models.WaakyeVendor, error) {
-	query := `
-		SELECT wv.id, wv.name, wv.description, wv.operating_hours,wv.image_url, wv.phone_number, wv.is_verified, wv.created_at, wv.updated_at,
-			l.street_address, l.city, l.region, l.latitude, l.longitude, l.landmark
-		FROM waakye_vendors wv
-		INNER JOIN locations l ON wv.location_id = l.id
-		ORDER BY wv.created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+func (r *vendorRepository) ListVendorsWithPagination(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, error) {
+	builder := &queryBuilder{}
+	applyVendorFilters(builder, params.Filter)
+
+	distance := "NULL::float8"
+	if params.Latitude != nil && params.Longitude != nil {
+		distance = distanceKmExpr(builder.arg(*params.Latitude), builder.arg(*params.Longitude))
+	}
 
-	offset := (page - 1) * pageSize
-	rows, err := r.db.QueryContext(ctx, query, pageSize, offset)
+	order, err := vendorSortSpec(params.Sort, distance)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to list vendors")
 		return nil, err
 	}
 
+	query := fmt.Sprintf(`
+		SELECT %s, COALESCE(%s, 0) AS distance_km
+		%s
+		%s
+		%s
+		LIMIT %s OFFSET %s
+	`, vendorColumns, distance, vendorFrom, builder.whereSQL(), order.orderSQL(),
+		builder.arg(params.PageSize), builder.arg((params.Page-1)*params.PageSize))
+
+	return r.queryVendors(ctx, query, builder.args, withDistance)
+}
+
+// ListVendorsAfter is the keyset paginated form of ListVendorsWithPagination. It seeks past the
+// cursor's sort key and ID instead of skipping rows, so deep pages stay cheap and rows inserted
+// while a client scrolls are neither skipped nor repeated.
+func (r *vendorRepository) ListVendorsAfter(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, *models.KeysetPage, error) {
+	if params.Sort == "" {
+		params.Sort = models.VendorSortNewest
+	}
+	if params.Cursor != nil && params.Cursor.Sort != string(params.Sort) {
+		return nil, nil, ErrCursorSortMismatch
+	}
+
+	builder := &queryBuilder{}
+	applyVendorFilters(builder, params.Filter)
+
+	distance := "NULL::float8"
+	if params.Latitude != nil && params.Longitude != nil {
+		distance = distanceKmExpr(builder.arg(*params.Latitude), builder.arg(*params.Longitude))
+	}
+
+	order, err := vendorSortSpec(params.Sort, distance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backward := false
+	if params.Cursor != nil {
+		if err := builder.whereAfter(order, "wv.id", params.Cursor); err != nil {
+			return nil, nil, err
+		}
+		backward = params.Cursor.Backward
+	}
+
+	// One extra row tells us whether there is another page
+	query := fmt.Sprintf(`
+		SELECT %s, COALESCE(%s, 0) AS distance_km, (%s)::text AS sort_key
+		%s
+		%s
+		%s
+		LIMIT %s
+	`, vendorColumns, distance, order.expr, vendorFrom, builder.whereSQL(),
+		order.keysetOrderSQL("wv.id", backward), builder.arg(params.PageSize+1))
+
+	rows, err := r.db.QueryContext(ctx, query, builder.args...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query vendors")
+		return nil, nil, err
+	}
 	defer rows.Close()
 
-	var vendors []models.WaakyeVendor
+	page := []keysetRow[models.WaakyeVendor]{}
 	for rows.Next() {
-		var vendor models.WaakyeVendor
-		err := rows.Scan(
-			&vendor.ID,
-			&vendor.Name,
-			&vendor.Description,
-			&vendor.OperatingHours,
-			&vendor.ImageURL,
-			&vendor.PhoneNumber,
-			&vendor.IsVerified,
-			&vendor.CreatedAt,
-			&vendor.UpdatedAt,
-			&vendor.Location.StreetAddress,
-			&vendor.Location.City,
-			&vendor.Location.Region,
-			&vendor.Location.Latitude,
-			&vendor.Location.Longitude,
-			&vendor.Location.Landmark,
-		)
-		if err != nil {
+		var row keysetRow[models.WaakyeVendor]
+		if err := scanVendor(rows, &row.item, &row.item.Distance, &row.key); err != nil {
 			log.Error().Err(err).Msg("Failed to scan vendor")
-			return nil, err
+			return nil, nil, err
 		}
-
-		vendors = append(vendors, vendor)
+		row.id = row.item.ID
+		page = append(page, row)
 	}
 
-	if len(vendors) == 0 {
-		return []models.WaakyeVendor{}, nil
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendors")
+		return nil, nil, err
 	}
 
-	return vendors, nil
-
+	vendors, keyset := finishKeysetPage(page, params.PageSize, string(params.Sort), params.Cursor)
+	if err := r.hours.attachOpenStatus(ctx, vendors); err != nil {
+		return nil, nil, err
+	}
+	return vendors, keyset, nil
 }
 
-func (r *vendorRepository) CountVendors(ctx context.Context) (int64, error) {
-	query := `SELECT COUNT(*) FROM waakye_vendors`
+func (r *vendorRepository) CountVendors(ctx context.Context, filter models.VendorFilter) (int64, error) {
+	builder := &queryBuilder{}
+	applyVendorFilters(builder, filter)
+
+	query := fmt.Sprintf(`SELECT COUNT(*) %s %s`, vendorFrom, builder.whereSQL())
 
 	var totalItems int64
-	err := r.db.QueryRowContext(ctx, query).Scan(&totalItems)
+	err := r.db.QueryRowContext(ctx, query, builder.args...).Scan(&totalItems)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to count vendors")
 		return 0, err
 	}
 
 	return totalItems, nil
-
 }
 
 func (r *vendorRepository) GetVendorByID(ctx context.Context, id uuid.UUID) (*models.WaakyeVendor, error) {
-    // First, get the vendor details
-    vendorQuery := `
-        SELECT wv.id, wv.name, wv.description, wv.operating_hours, wv.image_url, wv.phone_number, 
-               wv.is_verified, wv.created_at, wv.updated_at,
-               l.street_address, l.city, l.region, l.latitude, l.longitude, l.landmark,
-               COALESCE(AVG((vr.hygiene_rating + vr.value_rating + vr.taste_rating + vr.service_rating) / 4), 0) as avg_rating,
-               COALESCE(AVG(vr.hygiene_rating), 0) as avg_hygiene_rating,
-               COALESCE(AVG(vr.value_rating), 0) as avg_value_rating,
-               COALESCE(AVG(vr.taste_rating), 0) as avg_taste_rating,
-               COALESCE(AVG(vr.service_rating), 0) as avg_service_rating
-        FROM waakye_vendors wv
-        INNER JOIN locations l ON wv.location_id = l.id
-        LEFT JOIN vendor_ratings vr ON wv.id = vr.vendor_id
-        WHERE wv.id = $1
-        GROUP BY wv.id, l.street_address, l.city, l.region, l.latitude, l.longitude, l.landmark
-    `
-
-    var vendor models.WaakyeVendor
-    err := r.db.QueryRowContext(ctx, vendorQuery, id).Scan(
-        &vendor.ID,
-        &vendor.Name,
-        &vendor.Description,
-        &vendor.OperatingHours,
-        &vendor.ImageURL,
-        &vendor.PhoneNumber,
-        &vendor.IsVerified,
-        &vendor.CreatedAt,
-        &vendor.UpdatedAt,
-        &vendor.Location.StreetAddress,
-        &vendor.Location.City,
-        &vendor.Location.Region,
-        &vendor.Location.Latitude,
-        &vendor.Location.Longitude,
-        &vendor.Location.Landmark,
-        &vendor.AverageRating,
-        &vendor.AverageHygieneRating,
-        &vendor.AverageValueRating,
-        &vendor.AverageTasteRating,
-        &vendor.AverageServiceRating,
-    )
-    if err != nil {
-        log.Error().Err(err).Msg("Failed to get vendor by ID")
-        return nil, err
-    }
-
-    // Now get comments for this vendor
-    commentsQuery := `
-        SELECT id, hygiene_rating, value_rating, service_rating, taste_rating, comment, created_at
-        FROM vendor_ratings
-        WHERE vendor_id = $1
-        ORDER BY created_at DESC
-    `
-
-    commentsRows, err := r.db.QueryContext(ctx, commentsQuery, id)
-    if err != nil {
-        log.Error().Err(err).Msg("Failed to get comments for vendor")
-        return nil, err
-    }
-    defer commentsRows.Close()
-
-    var comments []models.VendorRating
-    for commentsRows.Next() {
-        var rating models.VendorRating
-        err := commentsRows.Scan(
-            &rating.ID,
-            &rating.HygieneRating,
-            &rating.ValueRating,
-            &rating.ServiceRating,
-            &rating.TasteRating,
-            &rating.Comment,
-            &rating.CreatedAt,
-        )
-        if err != nil {
-            log.Error().Err(err).Msg("Failed to scan comment")
-            return nil, err
-        }
-
-        comments = append(comments, rating)
-    }
-
-    // Add comments to the vendor
-    vendor.Ratings = comments
-
-    return &vendor, nil
-}
+	// First, get the vendor details
+	vendorQuery := fmt.Sprintf(`
+		SELECT %s,
+			COALESCE(rs.avg_hygiene_rating, 0),
+			COALESCE(rs.avg_value_rating, 0),
+			COALESCE(rs.avg_taste_rating, 0),
+			COALESCE(rs.avg_service_rating, 0)
+		%s
+		WHERE wv.id = $1 AND wv.deleted_at IS NULL
+	`, vendorColumns, vendorFrom)
+
+	var vendor models.WaakyeVendor
+	err := scanVendor(r.db.QueryRowContext(ctx, vendorQuery, id), &vendor,
+		&vendor.AverageHygieneRating,
+		&vendor.AverageValueRating,
+		&vendor.AverageTasteRating,
+		&vendor.AverageServiceRating,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVendorNotFound
+		}
+		log.Error().Err(err).Msg("Failed to get vendor by ID")
+		return nil, err
+	}
 
-func (r *vendorRepository) GetNearbyVendors(ctx context.Context, latitude, longitude, radiusKm float64) ([]models.WaakyeVendor, error) {
-    // Convert radius from kilometers to meters
-    radiusMeters := radiusKm * 1000.0
-
-    query := `
-        SELECT 
-            wv.id, 
-            wv.name, 
-            wv.description, 
-            wv.operating_hours, 
-            wv.image_url,
-            wv.phone_number, 
-            wv.is_verified, 
-            wv.created_at, 
-            wv.updated_at,
-            l.street_address, 
-            l.city, 
-            l.region, 
-            l.latitude, 
-            l.longitude, 
-            l.landmark,
-            earth_distance(ll_to_earth($1, $2), ll_to_earth(l.latitude, l.longitude)) as distance,
-            COALESCE(AVG((vr.hygiene_rating + vr.value_rating + vr.taste_rating + vr.service_rating) / 4), 0) as avg_rating
-        FROM waakye_vendors wv
-        INNER JOIN locations l ON wv.location_id = l.id
-        LEFT JOIN vendor_ratings vr ON wv.id = vr.vendor_id
-        WHERE earth_distance(ll_to_earth($1, $2), ll_to_earth(l.latitude, l.longitude)) <= $3
-        GROUP BY wv.id, l.id
-        ORDER BY distance ASC
-    `
-
-    rows, err := r.db.QueryContext(ctx, query, latitude, longitude, radiusMeters)
-    if err != nil {
-        log.Error().Err(err).
-            Float64("latitude", latitude).
-            Float64("longitude", longitude).
-            Float64("radius_km", radiusKm).
-            Msg("Failed to get nearby vendors")
-        return nil, err
-    }
-    defer rows.Close()
-
-    var vendors []models.WaakyeVendor
-    for rows.Next() {
-        var vendor models.WaakyeVendor
-        var distance float64
-        var avgRating float64
-        err := rows.Scan(
-            &vendor.ID,
-            &vendor.Name,
-            &vendor.Description,
-            &vendor.OperatingHours,
-            &vendor.ImageURL,
-            &vendor.PhoneNumber,
-            &vendor.IsVerified,
-            &vendor.CreatedAt,
-            &vendor.UpdatedAt,
-            &vendor.Location.StreetAddress,
-            &vendor.Location.City,
-            &vendor.Location.Region,
-            &vendor.Location.Latitude,
-            &vendor.Location.Longitude,
-            &vendor.Location.Landmark,
-            &distance,
-            &avgRating,
-        )
-        if err != nil {
-            log.Error().Err(err).Msg("Failed to scan vendor")
-            return nil, err
-        }
-
-        // Convert distance from meters to kilometers and add to vendor
-        vendor.Distance = distance / 1000.0
-        
-        // Set the average rating
-        vendor.AverageRating = avgRating
-
-        vendors = append(vendors, vendor)
-    }
-
-    if len(vendors) == 0 {
-        return []models.WaakyeVendor{}, nil
-    }
-
-    return vendors, nil
+	if vendor.LatestReviews, err = listLatestReviews(ctx, r.db, id); err != nil {
+		return nil, err
+	}
+
+	if vendor.Gallery, err = listVendorGallery(ctx, r.db, &vendor); err != nil {
+		return nil, err
+	}
+
+	vendors := []models.WaakyeVendor{vendor}
+	if err := r.hours.attachOpenStatus(ctx, vendors); err != nil {
+		return nil, err
+	}
+
+	return &vendors[0], nil
 }
-func (r *vendorRepository) GetVerifiedVendors(ctx context.Context, page, pageSize int) ([]models.WaakyeVendor, error) {
-	query := `
-		SELECT wv.id, wv.name, wv.description, wv.operating_hours,wv.image_url ,wv.phone_number, wv.is_verified, wv.created_at, wv.updated_at,
-			l.street_address, l.city, l.region, l.latitude, l.longitude, l.landmark
-		FROM waakye_vendors wv
-		INNER JOIN locations l ON wv.location_id = l.id
-		WHERE wv.is_verified = true
-		ORDER BY wv.created_at DESC
-		LIMIT $1 OFFSET $2
-	`
 
-	offset := (page - 1) * pageSize
-	rows, err := r.db.QueryContext(ctx, query, pageSize, offset)
+// GetNearbyVendors returns vendors closest first. The earth_box test lets the GiST index on
+// locations discard far away rows before the exact distance check, and ordering by the cube
+// distance to the point lets nearest mode walk the same index without a radius.
+func (r *vendorRepository) GetNearbyVendors(ctx context.Context, params models.NearbyParams) ([]models.WaakyeVendor, error) {
+	offset := (params.Page - 1) * params.PageSize
+	limit := params.PageSize
+	if params.Limit > 0 {
+		if offset >= params.Limit {
+			return []models.WaakyeVendor{}, nil
+		}
+		limit = min(limit, params.Limit-offset)
+	}
+
+	builder := &queryBuilder{}
+	point, distance := applyNearbyFilters(builder, params)
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s AS distance_km
+		%s
+		%s
+		ORDER BY %s <-> %s, wv.id
+		LIMIT %s OFFSET %s
+	`, vendorColumns, distance, vendorFrom, builder.whereSQL(), locationEarthPoint, point,
+		builder.arg(limit), builder.arg(offset))
+
+	vendors, err := r.queryVendors(ctx, query, builder.args, withDistance)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to list vendors")
+		log.Error().Err(err).
+			Float64("latitude", params.Latitude).
+			Float64("longitude", params.Longitude).
+			Float64("radius_km", params.RadiusKm).
+			Bool("nearest", params.Nearest).
+			Msg("Failed to get nearby vendors")
 		return nil, err
 	}
 
-	defer rows.Close()
+	return vendors, nil
+}
 
-	var vendors []models.WaakyeVendor
+func (r *vendorRepository) CountNearbyVendors(ctx context.Context, params models.NearbyParams) (int64, error) {
+	builder := &queryBuilder{}
+	applyNearbyFilters(builder, params)
 
-	for rows.Next() {
-		var vendor models.WaakyeVendor
-		err := rows.Scan(
-			&vendor.ID,
-			&vendor.Name,
-			&vendor.Description,
-			&vendor.OperatingHours,
-			&vendor.ImageURL,
-			&vendor.PhoneNumber,
-			&vendor.IsVerified,
-			&vendor.CreatedAt,
-			&vendor.UpdatedAt,
-			&vendor.Location.StreetAddress,
-			&vendor.Location.City,
-			&vendor.Location.Region,
-			&vendor.Location.Latitude,
-			&vendor.Location.Longitude,
-			&vendor.Location.Landmark,
-		)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to scan vendor")
-			return nil, err
-		}
+	query := fmt.Sprintf(`SELECT COUNT(*) %s %s`, vendorFrom, builder.whereSQL())
+
+	var totalItems int64
+	if err := r.db.QueryRowContext(ctx, query, builder.args...).Scan(&totalItems); err != nil {
+		log.Error().Err(err).Msg("Failed to count nearby vendors")
+		return 0, err
+	}
 
-		vendors = append(vendors, vendor)
+	if params.Limit > 0 {
+		totalItems = min(totalItems, int64(params.Limit))
 	}
+	return totalItems, nil
+}
 
-	if len(vendors) == 0 {
-		return []models.WaakyeVendor{}, nil
+// applyNearbyFilters adds the listing filters and, outside nearest mode, the radius. It returns
+// the SQL for the searched point on the earth cube and for the distance to it in kilometres.
+func applyNearbyFilters(builder *queryBuilder, params models.NearbyParams) (string, string) {
+	latitude, longitude := builder.arg(params.Latitude), builder.arg(params.Longitude)
+	point := fmt.Sprintf("ll_to_earth(%s, %s)", latitude, longitude)
+	distance := distanceKmExpr(latitude, longitude)
+
+	applyVendorFilters(builder, params.Filter)
+	if !params.Nearest {
+		builder.where(fmt.Sprintf("earth_box(%s, ?) @> %s", point, locationEarthPoint), params.RadiusKm*1000)
+		builder.where(distance+" <= ?", params.RadiusKm)
 	}
 
-	return vendors, nil
+	return point, distance
+}
+
+func (r *vendorRepository) GetVerifiedVendors(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, error) {
+	verified := true
+	params.Filter.IsVerified = &verified
+	return r.ListVendorsWithPagination(ctx, params)
+}
+
+func (r *vendorRepository) GetVerifiedVendorsAfter(ctx context.Context, params models.VendorListParams) ([]models.WaakyeVendor, *models.KeysetPage, error) {
+	verified := true
+	params.Filter.IsVerified = &verified
+	return r.ListVendorsAfter(ctx, params)
+}
+
+func (r *vendorRepository) CountVerifiedVendors(ctx context.Context, filter models.VendorFilter) (int64, error) {
+	verified := true
+	filter.IsVerified = &verified
+	return r.CountVendors(ctx, filter)
+}
+
+// GetTopRatedVendors ranks vendors by a score that accounts for how many ratings they have, so a
+// single 5 star rating does not beat hundreds averaging 4.8
+func (r *vendorRepository) GetTopRatedVendors(ctx context.Context, params models.TopRatedParams) ([]models.WaakyeVendor, error) {
+	builder := &queryBuilder{}
+	if err := applyTopRatedFilters(builder, params); err != nil {
+		return nil, err
+	}
+
+	ranking := rankingScore(builder, params)
 
+	query := fmt.Sprintf(`
+		SELECT %s, %s AS rank_score
+		%s
+		%s
+		%s
+		ORDER BY rank_score DESC, rs.review_count DESC, wv.id DESC
+		LIMIT %s OFFSET %s
+	`, vendorColumns, ranking.score, vendorFrom, ranking.joins, builder.whereSQL(),
+		builder.arg(params.PageSize), builder.arg((params.Page-1)*params.PageSize))
+
+	return r.queryVendors(ctx, query, builder.args, withRankScore)
 }
 
-func (r *vendorRepository) CountVerifiedVendors(ctx context.Context) (int64, error) {
-	query := `SELECT COUNT(*) FROM waakye_vendors WHERE is_verified = true`
+func (r *vendorRepository) CountTopRatedVendors(ctx context.Context, params models.TopRatedParams) (int64, error) {
+	builder := &queryBuilder{}
+	if err := applyTopRatedFilters(builder, params); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(*) %s %s`, vendorFrom, builder.whereSQL())
 
 	var totalItems int64
-	err := r.db.QueryRowContext(ctx, query).Scan(&totalItems)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to count vendors")
+	if err := r.db.QueryRowContext(ctx, query, builder.args...).Scan(&totalItems); err != nil {
+		log.Error().Err(err).Msg("Failed to count top rated vendors")
 		return 0, err
 	}
 
 	return totalItems, nil
 }
 
-func (r *vendorRepository) GetTopRatedVendors(ctx context.Context) ([]models.WaakyeVendor, error) {
+func (r *vendorRepository) UpdateVendor(ctx context.Context, id, editorID uuid.UUID, update *models.UpdateVendorRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin vendor update transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	vendorColumns := map[string]interface{}{}
+	if update.Name != nil {
+		vendorColumns["name"] = *update.Name
+	}
+	if update.Description != nil {
+		vendorColumns["description"] = *update.Description
+	}
+	if update.OperatingHours != nil {
+		vendorColumns["operating_hours"] = *update.OperatingHours
+	}
+	if update.ImageID != nil {
+		// Linking an upload replaces any image the vendor had by URL
+		vendorColumns["image_url"] = nil
+		vendorColumns["image_upload_id"] = nil
+		if *update.ImageID != "" {
+			imageID, err := uuid.Parse(*update.ImageID)
+			if err != nil {
+				return ErrInvalidVendorImage
+			}
+			if err := checkVendorImage(ctx, tx, imageID, &editorID); err != nil {
+				return err
+			}
+			vendorColumns["image_upload_id"] = imageID
+		}
+	}
+	if update.PhoneNumber != nil {
+		vendorColumns["phone_number"] = *update.PhoneNumber
+	}
+
+	// updated_at is always bumped, even when only the location changes
+	setClause, args := buildSetClause(vendorColumns, 2)
+	vendorQuery := fmt.Sprintf(`
+		UPDATE waakye_vendors
+		SET %supdated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING location_id
+	`, setClause)
+
+	var locationID uuid.UUID
+	err = tx.QueryRowContext(ctx, vendorQuery, append([]interface{}{id}, args...)...).Scan(&locationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrVendorNotFound
+		}
+		log.Error().Err(err).Str("vendor_id", id.String()).Msg("Failed to update vendor")
+		return err
+	}
+
+	if update.Location != nil {
+		locationColumns := map[string]interface{}{}
+		if update.Location.StreetAddress != nil {
+			locationColumns["street_address"] = *update.Location.StreetAddress
+		}
+		if update.Location.City != nil {
+			locationColumns["city"] = *update.Location.City
+		}
+		if update.Location.Region != nil {
+			locationColumns["region"] = *update.Location.Region
+		}
+		if update.Location.Latitude != nil {
+			locationColumns["latitude"] = *update.Location.Latitude
+		}
+		if update.Location.Longitude != nil {
+			locationColumns["longitude"] = *update.Location.Longitude
+		}
+		if update.Location.Landmark != nil {
+			locationColumns["landmark"] = *update.Location.Landmark
+		}
+
+		if len(locationColumns) > 0 {
+			setClause, args := buildSetClause(locationColumns, 2)
+			locationQuery := fmt.Sprintf(`UPDATE locations SET %s WHERE id = $1`, strings.TrimSuffix(setClause, ", "))
+
+			if _, err := tx.ExecContext(ctx, locationQuery, append([]interface{}{locationID}, args...)...); err != nil {
+				log.Error().Err(err).Str("vendor_id", id.String()).Msg("Failed to update vendor location")
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit vendor update")
+		return err
+	}
+
+	return nil
+}
+
+func (r *vendorRepository) DeleteVendor(ctx context.Context, id uuid.UUID) error {
 	query := `
-		SELECT wv.id, wv.name, wv.description, wv.operating_hours,wv.image_url ,wv.phone_number, wv.is_verified, wv.created_at, wv.updated_at,
-			l.street_address, l.city, l.region, l.latitude, l.longitude, l.landmark
-		FROM waakye_vendors wv
-		INNER JOIN locations l ON wv.location_id = l.id
-		INNER JOIN vendor_ratings vr ON wv.id = vr.vendor_id
-		GROUP BY wv.id, l.street_address, l.city, l.region, l.latitude, l.longitude, l.landmark
-		ORDER BY AVG((vr.hygiene_rating + vr.value_rating + vr.taste_rating + vr.service_rating) / 4) DESC
-		LIMIT 5
+		UPDATE waakye_vendors
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to list vendors")
-		return nil, err
+		log.Error().Err(err).Str("vendor_id", id.String()).Msg("Failed to delete vendor")
+		return err
 	}
 
-	defer rows.Close()
+	return expectAffected(result, ErrVendorNotFound)
+}
 
-	var vendors []models.WaakyeVendor
+func (r *vendorRepository) RestoreVendor(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE waakye_vendors
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
 
-	for rows.Next() {
-		var vendor models.WaakyeVendor
-		err := rows.Scan(
-			&vendor.ID,
-			&vendor.Name,
-			&vendor.Description,
-			&vendor.OperatingHours,
-			&vendor.ImageURL,
-			&vendor.PhoneNumber,
-			&vendor.IsVerified,
-			&vendor.CreatedAt,
-			&vendor.UpdatedAt,
-			&vendor.Location.StreetAddress,
-			&vendor.Location.City,
-			&vendor.Location.Region,
-			&vendor.Location.Latitude,
-			&vendor.Location.Longitude,
-			&vendor.Location.Landmark,
-		)
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		log.Error().Err(err).Str("vendor_id", id.String()).Msg("Failed to restore vendor")
+		return err
+	}
 
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to scan vendor")
-			return nil, err
+	return expectAffected(result, ErrVendorNotFound)
+}
+
+// AssignVendorOwner hands a vendor listing to a user. Contributors are promoted to vendor owners
+// so they can manage the listing they were given.
+func (r *vendorRepository) AssignVendorOwner(ctx context.Context, id, ownerID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin owner assignment transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	promoteQuery := `
+		UPDATE users
+		SET role = 'vendor_owner', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND role = 'contributor'
+	`
+	if _, err := tx.ExecContext(ctx, promoteQuery, ownerID); err != nil {
+		log.Error().Err(err).Str("user_id", ownerID.String()).Msg("Failed to promote vendor owner")
+		return err
+	}
+
+	assignQuery := `
+		UPDATE waakye_vendors
+		SET owner_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	result, err := tx.ExecContext(ctx, assignQuery, id, ownerID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrUserNotFound
 		}
+		log.Error().Err(err).Str("vendor_id", id.String()).Msg("Failed to assign vendor owner")
+		return err
+	}
+	if err := expectAffected(result, ErrVendorNotFound); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit owner assignment")
+		return err
+	}
+
+	return nil
+}
 
-		vendors = append(vendors, vendor)
+// buildSetClause turns a column/value map into a "col = $n, " list with its arguments.
+// Columns are sorted so the generated SQL is stable. Placeholders start at startAt.
+func buildSetClause(columns map[string]interface{}, startAt int) (string, []interface{}) {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	if len(vendors) == 0 {
-		return []models.WaakyeVendor{}, nil
+	var clause strings.Builder
+	args := make([]interface{}, 0, len(names))
+	for i, name := range names {
+		fmt.Fprintf(&clause, "%s = $%d, ", name, startAt+i)
+		args = append(args, columns[name])
 	}
 
-	return vendors, nil
+	return clause.String(), args
+}
+
+// expectAffected returns notFound when the statement did not touch any row
+func expectAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
 }
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// applyBoundsFilter limits a vendor query to live vendors inside the viewport. The point expression
+// matches idx_locations_point so the lookup uses the index.
+func applyBoundsFilter(builder *queryBuilder, bounds models.BoundingBox, filter models.VendorFilter) {
+	applyVendorFilters(builder, filter)
+	builder.where("point(l.longitude::float8, l.latitude::float8) <@ box(point(?, ?), point(?, ?))",
+		bounds.MinLng, bounds.MinLat, bounds.MaxLng, bounds.MaxLat)
+}
+
+func (r *vendorRepository) CountVendorsInBounds(ctx context.Context, bounds models.BoundingBox, filter models.VendorFilter) (int64, error) {
+	builder := &queryBuilder{}
+	applyBoundsFilter(builder, bounds, filter)
+
+	query := fmt.Sprintf(`SELECT COUNT(*) %s %s`, vendorFrom, builder.whereSQL())
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, query, builder.args...).Scan(&total); err != nil {
+		log.Error().Err(err).Msg("Failed to count vendors in bounds")
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetVendorsInBounds returns up to limit vendors inside the viewport, best rated first so the
+// most useful markers survive when the limit cuts the list short
+func (r *vendorRepository) GetVendorsInBounds(ctx context.Context, bounds models.BoundingBox, filter models.VendorFilter, limit int) ([]models.MapPoint, error) {
+	builder := &queryBuilder{}
+	applyBoundsFilter(builder, bounds, filter)
+
+	query := fmt.Sprintf(`
+		SELECT wv.id, wv.name, l.latitude, l.longitude, COALESCE(rs.avg_rating, 0),
+			wv.verification_status = 'verified', 1, ''
+		%s
+		%s
+		ORDER BY COALESCE(rs.avg_rating, 0) DESC, wv.id
+		LIMIT %s
+	`, vendorFrom, builder.whereSQL(), builder.arg(limit))
+
+	return r.queryMapPoints(ctx, query, builder.args)
+}
+
+// ClusterVendorsInBounds groups the vendors inside the viewport into square grid cells of
+// cellDegrees and returns one point per cell at the centroid of its vendors. A cell holding a
+// single vendor comes back as that vendor.
+func (r *vendorRepository) ClusterVendorsInBounds(ctx context.Context, bounds models.BoundingBox, filter models.VendorFilter, cellDegrees float64) ([]models.MapPoint, error) {
+	builder := &queryBuilder{}
+	applyBoundsFilter(builder, bounds, filter)
+	cell := builder.arg(cellDegrees)
+
+	query := fmt.Sprintf(`
+		SELECT (array_agg(wv.id))[1], (array_agg(wv.name))[1],
+			AVG(l.latitude), AVG(l.longitude),
+			(array_agg(COALESCE(rs.avg_rating, 0)))[1],
+			bool_and(wv.verification_status = 'verified'),
+			COUNT(*),
+			floor(l.longitude / %[1]s)::bigint || ':' || floor(l.latitude / %[1]s)::bigint
+		%[2]s
+		%[3]s
+		GROUP BY floor(l.longitude / %[1]s), floor(l.latitude / %[1]s)
+	`, cell, vendorFrom, builder.whereSQL())
+
+	return r.queryMapPoints(ctx, query, builder.args)
+}
+
+func (r *vendorRepository) queryMapPoints(ctx context.Context, query string, args []interface{}) ([]models.MapPoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query map points")
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.MapPoint{}
+	for rows.Next() {
+		var point models.MapPoint
+		err := rows.Scan(
+			&point.ID,
+			&point.Name,
+			&point.Latitude,
+			&point.Longitude,
+			&point.AverageRating,
+			&point.IsVerified,
+			&point.Count,
+			&point.CellKey,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan map point")
+			return nil, err
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over map points")
+		return nil, err
+	}
+
+	return points, nil
+}
@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultVendorImageURL is the placeholder every vendor gets when no photo was provided
+const defaultVendorImageURL = "https://example.com/images/default-waakye-vendor.jpg"
+
+// vendorColumns are the columns every vendor query selects, in the order scanVendor reads them
+const vendorColumns = `
+	wv.id, wv.name, COALESCE(wv.description, ''), COALESCE(wv.operating_hours, ''),
+	wv.image_upload_id, COALESCE(vup.file_name, ''), COALESCE(wv.image_url, ''), COALESCE(wv.phone_number, ''),
+	wv.verification_status = 'verified', wv.verification_status, wv.created_by, wv.owner_id,
+	wv.created_at, wv.updated_at,
+	wv.location_id, l.street_address, l.city, l.region, l.latitude, l.longitude, COALESCE(l.landmark, ''),
+	COALESCE(rs.avg_rating, 0), rs.review_count, COALESCE(vup.variants, '{}')`
+
+// vendorFrom joins a vendor with its location, its uploaded image and the averages of its rating
+// stats. rs always has one row, with a review_count of zero and NULL averages for vendors nobody
+// has rated.
+const vendorFrom = `
+	FROM waakye_vendors wv
+	INNER JOIN locations l ON wv.location_id = l.id
+	LEFT JOIN uploads vup ON vup.id = wv.image_upload_id
+	LEFT JOIN vendor_rating_stats vrs ON vrs.vendor_id = wv.id
+	CROSS JOIN LATERAL (
+		SELECT
+			(vrs.hygiene_sum + vrs.value_sum + vrs.taste_sum + vrs.service_sum) / NULLIF(4.0 * vrs.rating_count, 0) AS avg_rating,
+			vrs.hygiene_sum / NULLIF(vrs.rating_count::numeric, 0) AS avg_hygiene_rating,
+			vrs.value_sum / NULLIF(vrs.rating_count::numeric, 0) AS avg_value_rating,
+			vrs.taste_sum / NULLIF(vrs.rating_count::numeric, 0) AS avg_taste_rating,
+			vrs.service_sum / NULLIF(vrs.rating_count::numeric, 0) AS avg_service_rating,
+			COALESCE(vrs.rating_count, 0)::bigint AS review_count
+	) rs`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanVendor reads vendorColumns into vendor, followed by any extra columns the query selected
+func scanVendor(scanner rowScanner, vendor *models.WaakyeVendor, extra ...interface{}) error {
+	var variants []byte
+	dest := []interface{}{
+		&vendor.ID,
+		&vendor.Name,
+		&vendor.Description,
+		&vendor.OperatingHours,
+		&vendor.ImageID,
+		&vendor.ImageKey,
+		&vendor.ImageURL,
+		&vendor.PhoneNumber,
+		&vendor.IsVerified,
+		&vendor.VerificationStatus,
+		&vendor.CreatedBy,
+		&vendor.OwnerID,
+		&vendor.CreatedAt,
+		&vendor.UpdatedAt,
+		&vendor.LocationID,
+		&vendor.Location.StreetAddress,
+		&vendor.Location.City,
+		&vendor.Location.Region,
+		&vendor.Location.Latitude,
+		&vendor.Location.Longitude,
+		&vendor.Location.Landmark,
+		&vendor.AverageRating,
+		&vendor.ReviewCount,
+		&variants,
+	}
+
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(variants, &vendor.ImageVariants); err != nil {
+		return fmt.Errorf("decode vendor image variants: %w", err)
+	}
+
+	vendor.Location.ID = vendor.LocationID
+	return nil
+}
+
+// vendorExtra points an extra selected column at a field of the vendor being scanned
+type vendorExtra func(vendor *models.WaakyeVendor) interface{}
+
+func withDistance(vendor *models.WaakyeVendor) interface{} { return &vendor.Distance }
+
+func withSearchScore(vendor *models.WaakyeVendor) interface{} { return &vendor.SearchScore }
+
+func withRankScore(vendor *models.WaakyeVendor) interface{} { return &vendor.RankScore }
+
+// queryVendors runs a query selecting vendorColumns plus the given extra columns
+func (r *vendorRepository) queryVendors(ctx context.Context, query string, args []interface{}, extras ...vendorExtra) ([]models.WaakyeVendor, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query vendors")
+		return nil, err
+	}
+	defer rows.Close()
+
+	vendors := []models.WaakyeVendor{}
+	for rows.Next() {
+		var vendor models.WaakyeVendor
+
+		extra := make([]interface{}, 0, len(extras))
+		for _, field := range extras {
+			extra = append(extra, field(&vendor))
+		}
+
+		if err := scanVendor(rows, &vendor, extra...); err != nil {
+			log.Error().Err(err).Msg("Failed to scan vendor")
+			return nil, err
+		}
+		vendors = append(vendors, vendor)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over vendors")
+		return nil, err
+	}
+
+	if err := r.hours.attachOpenStatus(ctx, vendors); err != nil {
+		return nil, err
+	}
+
+	return vendors, nil
+}
+
+// locationEarthPoint is a vendor's location on the earth cube; it matches idx_locations_earth
+const locationEarthPoint = "ll_to_earth(l.latitude, l.longitude)"
+
+// distanceKmExpr is the great circle distance in kilometres between a point and a vendor's location
+func distanceKmExpr(latPlaceholder, lngPlaceholder string) string {
+	return fmt.Sprintf("(earth_distance(ll_to_earth(%s, %s), %s) / 1000.0)", latPlaceholder, lngPlaceholder, locationEarthPoint)
+}
+
+// applyVendorFilters adds the conditions shared by a vendor listing and its count
+func applyVendorFilters(builder *queryBuilder, filter models.VendorFilter) {
+	builder.where("wv.deleted_at IS NULL")
+
+	if filter.City != "" {
+		builder.where("l.city = ?", filter.City)
+	}
+	if filter.Region != "" {
+		builder.where("l.region = ?", filter.Region)
+	}
+	if filter.IsVerified != nil {
+		if *filter.IsVerified {
+			builder.where("wv.verification_status = 'verified'")
+		} else {
+			builder.where("wv.verification_status <> 'verified'")
+		}
+	}
+	if filter.MinRating != nil {
+		builder.where("COALESCE(rs.avg_rating, 0) >= ?", *filter.MinRating)
+	}
+	if filter.HasImage != nil {
+		hasImage := "(wv.image_upload_id IS NOT NULL OR (wv.image_url IS NOT NULL AND wv.image_url <> '' AND wv.image_url <> ?))"
+		if *filter.HasImage {
+			builder.where(hasImage, defaultVendorImageURL)
+		} else {
+			builder.where("NOT "+hasImage, defaultVendorImageURL)
+		}
+	}
+	if filter.OpenNow != nil {
+		if *filter.OpenNow {
+			builder.where("vendor_is_open(wv.id, now())")
+		} else {
+			builder.where("NOT vendor_is_open(wv.id, now())")
+		}
+	}
+}
+
+// vendorSortSpec maps a requested sort onto its ORDER BY expression.
+// distance is the SQL expression for the distance to the caller, NULL when no location was sent.
+func vendorSortSpec(sort models.VendorSort, distance string) (sortSpec, error) {
+	switch sort {
+	case "", models.VendorSortNewest:
+		return sortSpec{expr: "wv.created_at", cast: "timestamptz", desc: true}, nil
+	case models.VendorSortName:
+		return sortSpec{expr: "wv.name", cast: "text", desc: false}, nil
+	case models.VendorSortRating:
+		return sortSpec{expr: "COALESCE(rs.avg_rating, 0)", cast: "numeric", desc: true}, nil
+	case models.VendorSortReviews:
+		return sortSpec{expr: "rs.review_count", cast: "bigint", desc: true}, nil
+	case models.VendorSortDistance:
+		if distance == "NULL::float8" {
+			return sortSpec{}, ErrDistanceSortNeedsLocation
+		}
+		return sortSpec{expr: distance, cast: "float8", desc: false}, nil
+	}
+
+	return sortSpec{}, fmt.Errorf("%w: %q", ErrUnknownSort, sort)
+}
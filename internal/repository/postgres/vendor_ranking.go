@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aglili/waakye-directory/internal/models"
+)
+
+// ErrUnknownRanking is returned when vendors are ranked by an unsupported method or dimension
+var ErrUnknownRanking = errors.New("unknown ranking")
+
+// wilsonZ is the normal quantile of the 95% confidence interval used by the Wilson lower bound
+const wilsonZ = 1.96
+
+// ratingDimension is where a rating dimension lives: the vendor's average in vendorFrom, and the
+// sum of its stars in a vendor_rating_stats row ps along with how many stars each rating adds
+type ratingDimension struct {
+	average string
+	sum     string
+	parts   int
+}
+
+var ratingDimensions = map[models.RatingDimension]ratingDimension{
+	models.RatingDimensionOverall: {
+		average: "rs.avg_rating",
+		sum:     "ps.hygiene_sum + ps.value_sum + ps.taste_sum + ps.service_sum",
+		parts:   4,
+	},
+	models.RatingDimensionTaste:   {average: "rs.avg_taste_rating", sum: "ps.taste_sum", parts: 1},
+	models.RatingDimensionHygiene: {average: "rs.avg_hygiene_rating", sum: "ps.hygiene_sum", parts: 1},
+	models.RatingDimensionValue:   {average: "rs.avg_value_rating", sum: "ps.value_sum", parts: 1},
+	models.RatingDimensionService: {average: "rs.avg_service_rating", sum: "ps.service_sum", parts: 1},
+}
+
+// ranking is the score top rated vendors are ordered by and the joins the score needs
+type ranking struct {
+	score string
+	joins string
+}
+
+// applyTopRatedFilters adds the conditions shared by a top rated listing and its count
+func applyTopRatedFilters(builder *queryBuilder, params models.TopRatedParams) error {
+	if !params.Method.Valid() {
+		return fmt.Errorf("%w method: %q", ErrUnknownRanking, params.Method)
+	}
+	if _, ok := ratingDimensions[params.Dimension]; !ok {
+		return fmt.Errorf("%w dimension: %q", ErrUnknownRanking, params.Dimension)
+	}
+
+	applyVendorFilters(builder, models.VendorFilter{City: params.City, Region: params.Region})
+	builder.where("rs.review_count >= ?", max(params.MinReviews, 1))
+	return nil
+}
+
+// rankingScore builds the score of a validated ranking, on the same 1-5 scale as the ratings.
+//
+// The Bayesian average is (C*m + n*avg) / (C + n), where m is the mean rating in the ranked
+// scope and C is the prior weight. The Wilson score maps the average onto a 0-1 proportion p
+// and takes the lower bound of its confidence interval over the n ratings.
+func rankingScore(builder *queryBuilder, params models.TopRatedParams) ranking {
+	dimension := ratingDimensions[params.Dimension]
+
+	if params.Method == models.RankingWilson {
+		const z2 = wilsonZ * wilsonZ
+		return ranking{
+			score: fmt.Sprintf(`(1 + 4 * (rk.p + %[1]g / (2 * rk.n) - %[2]g * sqrt((rk.p * (1 - rk.p) + %[1]g / (4 * rk.n)) / rk.n))
+				/ (1 + %[1]g / rk.n))::float8`, z2, wilsonZ),
+			joins: fmt.Sprintf(`CROSS JOIN LATERAL (
+				SELECT (COALESCE(%s, 1) - 1) / 4.0 AS p, rs.review_count::numeric AS n
+			) rk`, dimension.average),
+		}
+	}
+
+	prior := &queryBuilder{}
+	prior.where("pv.deleted_at IS NULL")
+	if params.City != "" {
+		prior.where("pl.city = " + builder.arg(params.City))
+	}
+	if params.Region != "" {
+		prior.where("pl.region = " + builder.arg(params.Region))
+	}
+
+	weight := builder.arg(params.PriorWeight)
+	return ranking{
+		score: fmt.Sprintf("((%[1]s * prior.mean + rs.review_count * COALESCE(%[2]s, 0)) / (%[1]s + rs.review_count))::float8",
+			weight+"::numeric", dimension.average),
+		joins: fmt.Sprintf(`CROSS JOIN (
+				SELECT COALESCE(SUM(%s) / NULLIF(%d.0 * SUM(ps.rating_count), 0), 0) AS mean
+				FROM vendor_rating_stats ps
+				INNER JOIN waakye_vendors pv ON pv.id = ps.vendor_id
+				INNER JOIN locations pl ON pl.id = pv.location_id
+				%s
+			) prior`, dimension.sum, dimension.parts, prior.whereSQL()),
+	}
+}
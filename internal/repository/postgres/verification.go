@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidVerificationTransition is returned when the workflow does not allow the requested state change
+var ErrInvalidVerificationTransition = errors.New("invalid verification state transition")
+
+// ErrInvalidEvidence is returned when verification evidence is not an image uploaded by the submitter
+var ErrInvalidEvidence = errors.New("verification evidence must be images uploaded by the submitter")
+
+type VerificationRepository interface {
+	GetVerification(ctx context.Context, vendorID uuid.UUID) (*models.VendorVerification, error)
+	SubmitEvidence(ctx context.Context, vendorID, userID uuid.UUID, moderator bool, request *models.SubmitVerificationRequest) error
+	TransitionVerification(ctx context.Context, vendorID, actorID uuid.UUID, to models.VerificationStatus, notes string) error
+	ListVerificationQueue(ctx context.Context, status models.VerificationStatus, page, pageSize int) ([]models.VerificationQueueItem, error)
+	CountVerificationQueue(ctx context.Context, status models.VerificationStatus) (int64, error)
+}
+
+type verificationRepository struct {
+	db *sql.DB
+}
+
+func NewVerificationRepository(db *sql.DB) VerificationRepository {
+	return &verificationRepository{
+		db: db,
+	}
+}
+
+func (r *verificationRepository) GetVerification(ctx context.Context, vendorID uuid.UUID) (*models.VendorVerification, error) {
+	vendorQuery := `
+		SELECT id, name, verification_status
+		FROM waakye_vendors
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	verification := models.VendorVerification{
+		Evidence: []models.VerificationEvidence{},
+		History:  []models.VerificationHistoryEntry{},
+	}
+	err := r.db.QueryRowContext(ctx, vendorQuery, vendorID).Scan(
+		&verification.VendorID,
+		&verification.VendorName,
+		&verification.Status,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVendorNotFound
+		}
+		log.Error().Err(err).Msg("Failed to get vendor verification")
+		return nil, err
+	}
+
+	evidenceQuery := `
+		SELECT e.id, e.vendor_id, e.upload_id, COALESCE(up.file_name, ''), COALESCE(e.file_url, ''),
+			COALESCE(e.caption, ''), e.submitted_by, e.created_at
+		FROM vendor_verification_evidence e
+		LEFT JOIN uploads up ON up.id = e.upload_id
+		WHERE e.vendor_id = $1
+		ORDER BY e.created_at DESC
+	`
+
+	evidenceRows, err := r.db.QueryContext(ctx, evidenceQuery, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get verification evidence")
+		return nil, err
+	}
+	defer evidenceRows.Close()
+
+	for evidenceRows.Next() {
+		var evidence models.VerificationEvidence
+		err := evidenceRows.Scan(
+			&evidence.ID,
+			&evidence.VendorID,
+			&evidence.UploadID,
+			&evidence.Key,
+			&evidence.FileURL,
+			&evidence.Caption,
+			&evidence.SubmittedBy,
+			&evidence.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan verification evidence")
+			return nil, err
+		}
+		verification.Evidence = append(verification.Evidence, evidence)
+	}
+	if err := evidenceRows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over verification evidence")
+		return nil, err
+	}
+
+	historyQuery := `
+		SELECT id, vendor_id, from_status, to_status, actor_id, COALESCE(notes, ''), created_at
+		FROM vendor_verification_history
+		WHERE vendor_id = $1
+		ORDER BY created_at DESC
+	`
+
+	historyRows, err := r.db.QueryContext(ctx, historyQuery, vendorID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get verification history")
+		return nil, err
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var entry models.VerificationHistoryEntry
+		err := historyRows.Scan(
+			&entry.ID,
+			&entry.VendorID,
+			&entry.FromStatus,
+			&entry.ToStatus,
+			&entry.ActorID,
+			&entry.Notes,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan verification history")
+			return nil, err
+		}
+		verification.History = append(verification.History, entry)
+	}
+	if err := historyRows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over verification history")
+		return nil, err
+	}
+
+	return &verification, nil
+}
+
+// SubmitEvidence attaches evidence to a vendor. When userID created or owns the vendor, or is a
+// moderator, pending or rejected vendors also move into review; anyone else only adds evidence.
+// Evidence added while a vendor is already under review or verified does not change its state.
+// Every piece of evidence has to be an image uploaded by userID, otherwise nothing is stored and
+// ErrInvalidEvidence is returned.
+func (r *verificationRepository) SubmitEvidence(ctx context.Context, vendorID, userID uuid.UUID, moderator bool, request *models.SubmitVerificationRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin evidence transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := lockVerificationStatus(ctx, tx, vendorID)
+	if err != nil {
+		return err
+	}
+
+	evidenceQuery := `
+		INSERT INTO vendor_verification_evidence (vendor_id, upload_id, caption, submitted_by)
+		VALUES ($1, $2, $3, $4)
+	`
+	for _, evidence := range request.Evidence {
+		if err := checkVendorImage(ctx, tx, evidence.UploadID, &userID); err != nil {
+			if errors.Is(err, ErrInvalidVendorImage) {
+				return ErrInvalidEvidence
+			}
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, evidenceQuery, vendorID, evidence.UploadID, evidence.Caption, userID); err != nil {
+			log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to store verification evidence")
+			return err
+		}
+	}
+
+	if current.CanTransitionTo(models.VerificationUnderReview) {
+		canStartReview := moderator
+		if !canStartReview {
+			if canStartReview, err = isVendorCreatorOrOwner(ctx, tx, vendorID, userID); err != nil {
+				return err
+			}
+		}
+		if canStartReview {
+			if err := setVerificationStatus(ctx, tx, vendorID, userID, current, models.VerificationUnderReview, request.Notes); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit verification evidence")
+		return err
+	}
+
+	return nil
+}
+
+func (r *verificationRepository) TransitionVerification(ctx context.Context, vendorID, actorID uuid.UUID, to models.VerificationStatus, notes string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to begin verification transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := lockVerificationStatus(ctx, tx, vendorID)
+	if err != nil {
+		return err
+	}
+
+	if !current.CanTransitionTo(to) {
+		return ErrInvalidVerificationTransition
+	}
+
+	if err := setVerificationStatus(ctx, tx, vendorID, actorID, current, to, notes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Msg("Failed to commit verification transition")
+		return err
+	}
+
+	return nil
+}
+
+func (r *verificationRepository) ListVerificationQueue(ctx context.Context, status models.VerificationStatus, page, pageSize int) ([]models.VerificationQueueItem, error) {
+	query := `
+		SELECT wv.id, wv.name, l.city, l.region, wv.verification_status,
+			(SELECT COUNT(*) FROM vendor_verification_evidence e WHERE e.vendor_id = wv.id) AS evidence_count,
+			(SELECT MAX(h.created_at) FROM vendor_verification_history h WHERE h.vendor_id = wv.id) AS last_changed_at
+		FROM waakye_vendors wv
+		INNER JOIN locations l ON wv.location_id = l.id
+		WHERE wv.verification_status = $1 AND wv.deleted_at IS NULL
+		ORDER BY last_changed_at ASC NULLS FIRST, wv.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	offset := (page - 1) * pageSize
+	rows, err := r.db.QueryContext(ctx, query, status, pageSize, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list verification queue")
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue := []models.VerificationQueueItem{}
+	for rows.Next() {
+		var item models.VerificationQueueItem
+		err := rows.Scan(
+			&item.VendorID,
+			&item.VendorName,
+			&item.City,
+			&item.Region,
+			&item.Status,
+			&item.EvidenceCount,
+			&item.LastChangedAt,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to scan verification queue item")
+			return nil, err
+		}
+		queue = append(queue, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).Msg("Error iterating over verification queue")
+		return nil, err
+	}
+
+	return queue, nil
+}
+
+func (r *verificationRepository) CountVerificationQueue(ctx context.Context, status models.VerificationStatus) (int64, error) {
+	query := `SELECT COUNT(*) FROM waakye_vendors WHERE verification_status = $1 AND deleted_at IS NULL`
+
+	var totalItems int64
+	if err := r.db.QueryRowContext(ctx, query, status).Scan(&totalItems); err != nil {
+		log.Error().Err(err).Msg("Failed to count verification queue")
+		return 0, err
+	}
+
+	return totalItems, nil
+}
+
+// lockVerificationStatus reads a vendor's verification state and locks the row until the transaction ends
+func lockVerificationStatus(ctx context.Context, tx *sql.Tx, vendorID uuid.UUID) (models.VerificationStatus, error) {
+	query := `
+		SELECT verification_status
+		FROM waakye_vendors
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	var status models.VerificationStatus
+	if err := tx.QueryRowContext(ctx, query, vendorID).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrVendorNotFound
+		}
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to lock vendor verification")
+		return "", err
+	}
+
+	return status, nil
+}
+
+// isVendorCreatorOrOwner reports whether userID added the vendor to the directory or owns it
+func isVendorCreatorOrOwner(ctx context.Context, tx *sql.Tx, vendorID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM waakye_vendors
+			WHERE id = $1 AND (created_by = $2 OR owner_id = $2)
+		)
+	`
+
+	var related bool
+	if err := tx.QueryRowContext(ctx, query, vendorID, userID).Scan(&related); err != nil {
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to check vendor creator and owner")
+		return false, err
+	}
+
+	return related, nil
+}
+
+// setVerificationStatus moves a vendor to a new state and records the change in its history
+func setVerificationStatus(ctx context.Context, tx *sql.Tx, vendorID, actorID uuid.UUID, from, to models.VerificationStatus, notes string) error {
+	updateQuery := `
+		UPDATE waakye_vendors
+		SET verification_status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateQuery, vendorID, to); err != nil {
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to update verification status")
+		return err
+	}
+
+	historyQuery := `
+		INSERT INTO vendor_verification_history (vendor_id, from_status, to_status, actor_id, notes)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.ExecContext(ctx, historyQuery, vendorID, from, to, actorID, notes); err != nil {
+		log.Error().Err(err).Str("vendor_id", vendorID.String()).Msg("Failed to record verification history")
+		return err
+	}
+
+	return nil
+}
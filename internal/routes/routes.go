@@ -6,7 +6,9 @@ import (
 	"time"
 
 	_ "github.com/aglili/waakye-directory/docs"
+	"github.com/aglili/waakye-directory/internal/middleware"
 	"github.com/aglili/waakye-directory/internal/provider"
+	"github.com/aglili/waakye-directory/internal/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -18,8 +20,8 @@ func SetupRoutes(provider *provider.Provider) http.Handler {
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -27,22 +29,77 @@ func SetupRoutes(provider *provider.Provider) http.Handler {
 
 	router.Use(gin.Recovery())
 
-	uploadsDir := filepath.Join(provider.Cfg.FileUploadPath)
-	router.StaticFS("/uploads", http.Dir(uploadsDir))
+	// Other backends stream files through the API, so the default UPLOAD_PUBLIC_URL works with them too
+	if provider.Cfg.StorageBackend == storage.BackendLocal {
+		uploadsDir := filepath.Join(provider.Cfg.FileUploadPath)
+		router.StaticFS("/uploads", http.Dir(uploadsDir))
+	} else {
+		router.GET("/uploads/*key", provider.UploadHandler.ServeUpload)
+	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
 	v1 := router.Group("/api/v1")
+	requireAuth := middleware.RequireAuth(provider.Tokens)
 
-	v1.POST("/vendors", provider.VendorHandler.CreateVendor)
+	v1.POST("/auth/signup", provider.AuthHandler.Signup)
+	v1.POST("/auth/login", provider.AuthHandler.Login)
+	v1.POST("/auth/refresh", provider.AuthHandler.Refresh)
+	v1.POST("/auth/logout", provider.AuthHandler.Logout)
+	v1.GET("/auth/me", requireAuth, provider.AuthHandler.Me)
+
+	v1.POST("/vendors", requireAuth, middleware.RequirePermission(middleware.PermVendorCreate), provider.VendorHandler.CreateVendor)
 	v1.GET("/vendors", provider.VendorHandler.ListVendorsWithPagination)
 	v1.GET("/vendors/:id", provider.VendorHandler.GetVendorByID)
+	v1.PUT("/vendors/:id", requireAuth, middleware.RequirePermission(middleware.PermVendorEditOwn, middleware.PermVendorEditAny), provider.VendorHandler.ReplaceVendor)
+	v1.PATCH("/vendors/:id", requireAuth, middleware.RequirePermission(middleware.PermVendorEditOwn, middleware.PermVendorEditAny), provider.VendorHandler.UpdateVendor)
+	v1.DELETE("/vendors/:id", requireAuth, middleware.RequirePermission(middleware.PermVendorDeleteOwn, middleware.PermVendorDeleteAny), provider.VendorHandler.DeleteVendor)
 	v1.GET("/vendors/nearby", provider.VendorHandler.GetNearbyVendors)
+	v1.GET("/vendors/map", provider.VendorHandler.GetVendorMap)
+	v1.GET("/vendors/search", provider.VendorHandler.SearchVendors)
 	v1.GET("/vendors/verified", provider.VendorHandler.GetVerifiedVendors)
 	v1.GET("/vendors/top_rated", provider.VendorHandler.GetTopRatedVendors)
-	v1.POST("/vendors/:id/rate", provider.VendorHandler.RateVendor)
+	v1.POST("/vendors/:id/rate", requireAuth, middleware.RequirePermission(middleware.PermRatingCreate), provider.VendorHandler.RateVendor)
 	v1.GET("/vendors/:id/ratings", provider.VendorHandler.GetVendorRatings)
+	v1.GET("/vendors/:id/ratings/stats", provider.VendorHandler.GetVendorRatingStats)
+	v1.PUT("/vendors/:id/ratings/:rating_id", requireAuth, provider.VendorHandler.UpdateRating)
+	v1.DELETE("/vendors/:id/ratings/:rating_id", requireAuth, provider.VendorHandler.DeleteRating)
+	v1.GET("/vendors/:id/ratings/:rating_id/revisions", requireAuth, middleware.RequirePermission(middleware.PermRatingModerate), provider.VendorHandler.ListRatingRevisions)
+	v1.GET("/vendors/:id/reviews", provider.VendorHandler.ListVendorReviews)
+	v1.POST("/vendors/:id/reviews/:rating_id/reports", requireAuth, middleware.RequirePermission(middleware.PermReviewReport), provider.ModerationHandler.ReportReview)
+	v1.POST("/vendors/:id/reviews/:rating_id/helpful", requireAuth, middleware.RequirePermission(middleware.PermReviewVote), provider.VendorHandler.MarkReviewHelpful)
+	v1.DELETE("/vendors/:id/reviews/:rating_id/helpful", requireAuth, middleware.RequirePermission(middleware.PermReviewVote), provider.VendorHandler.UnmarkReviewHelpful)
+	v1.POST("/vendors/:id/reviews/:rating_id/reply", requireAuth, middleware.RequirePermission(middleware.PermReviewReply), provider.ReviewReplyHandler.CreateReply)
+	v1.PUT("/vendors/:id/reviews/:rating_id/reply", requireAuth, middleware.RequirePermission(middleware.PermReviewReply), provider.ReviewReplyHandler.UpdateReply)
+	v1.DELETE("/vendors/:id/reviews/:rating_id/reply", requireAuth, middleware.RequirePermission(middleware.PermReviewReply, middleware.PermRatingModerate), provider.ReviewReplyHandler.DeleteReply)
+	v1.GET("/vendors/:id/hours", provider.VendorHandler.GetVendorHours)
+	v1.PUT("/vendors/:id/hours", requireAuth, middleware.RequirePermission(middleware.PermVendorEditOwn, middleware.PermVendorEditAny), provider.VendorHandler.SetVendorHours)
+	v1.POST("/vendors/:id/hours/overrides", requireAuth, middleware.RequirePermission(middleware.PermVendorEditOwn, middleware.PermVendorEditAny), provider.VendorHandler.AddHoursOverride)
+	v1.DELETE("/vendors/:id/hours/overrides/:override_id", requireAuth, middleware.RequirePermission(middleware.PermVendorEditOwn, middleware.PermVendorEditAny), provider.VendorHandler.DeleteHoursOverride)
+	v1.POST("/vendors/:id/verification", requireAuth, middleware.RequirePermission(middleware.PermEvidenceSubmit), provider.VerificationHandler.SubmitVerification)
+
+	v1.GET("/notifications", requireAuth, provider.NotificationHandler.ListNotifications)
+	v1.POST("/notifications/read", requireAuth, provider.NotificationHandler.MarkAllNotificationsRead)
+	v1.POST("/notifications/:id/read", requireAuth, provider.NotificationHandler.MarkNotificationRead)
+
+	v1.POST("/uploads", requireAuth, middleware.RequirePermission(middleware.PermUploadCreate), provider.UploadHandler.UploadFile)
+
+	admin := v1.Group("/admin", requireAuth)
+	admin.POST("/vendors/:id/restore", middleware.RequirePermission(middleware.PermVendorRestore), provider.VendorHandler.RestoreVendor)
+	admin.PUT("/vendors/:id/owner", middleware.RequirePermission(middleware.PermVendorAssign), provider.VendorHandler.AssignVendorOwner)
+	admin.PUT("/users/:id/role", middleware.RequirePermission(middleware.PermUserManage), provider.UserHandler.UpdateUserRole)
+
+	verifications := admin.Group("/verifications", middleware.RequirePermission(middleware.PermVendorVerify))
+	verifications.GET("", provider.VerificationHandler.ListVerificationQueue)
+	verifications.GET("/:id", provider.VerificationHandler.GetVerification)
+	verifications.POST("/:id/approve", provider.VerificationHandler.ApproveVerification)
+	verifications.POST("/:id/reject", provider.VerificationHandler.RejectVerification)
 
-	v1.POST("/uploads", provider.UploadHandler.UploadFile)
+	reviews := admin.Group("/reviews", middleware.RequirePermission(middleware.PermRatingModerate))
+	reviews.GET("", provider.ModerationHandler.ListReviewQueue)
+	reviews.GET("/:id", provider.ModerationHandler.GetReviewModeration)
+	reviews.POST("/:id/hide", provider.ModerationHandler.HideReview)
+	reviews.POST("/:id/restore", provider.ModerationHandler.RestoreReview)
+	reviews.DELETE("/:id", provider.ModerationHandler.DeleteReview)
 	return router
 }
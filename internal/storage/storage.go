@@ -0,0 +1,59 @@
+// Package storage keeps uploaded files somewhere every API replica can reach them: the local
+// filesystem for a single instance, or an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aglili/waakye-directory/internal/config"
+)
+
+// Storage backends selectable through STORAGE_BACKEND
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound is returned by Get when nothing is stored under the key
+var ErrNotFound = errors.New("stored file not found")
+
+// Storage stores files under slash-separated keys
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing anything already there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the file stored under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key. Deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL is where clients download the file stored under key
+	URL(key string) string
+}
+
+// New builds the storage backend picked in the configuration
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case BackendLocal:
+		return NewLocalStorage(cfg.FileUploadPath, cfg.UploadPublicURL)
+	case BackendS3:
+		return NewS3Storage(S3Options{
+			Endpoint:       cfg.S3Endpoint,
+			Region:         cfg.S3Region,
+			Bucket:         cfg.S3Bucket,
+			AccessKey:      cfg.S3AccessKey,
+			SecretKey:      cfg.S3SecretKey,
+			ForcePathStyle: cfg.S3ForcePathStyle,
+			PublicURL:      cfg.UploadPublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// joinURL appends key to a public base URL
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(key, "/")
+}
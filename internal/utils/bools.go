@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseOptionalBool parses an optional boolean query parameter.
+// It returns nil when the parameter is absent.
+func ParseOptionalBool(c *gin.Context, param string) (*bool, error) {
+	valueStr, ok := c.GetQuery(param)
+	if !ok || valueStr == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' value: must be true or false", param)
+	}
+
+	return &value, nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/aglili/waakye-directory/internal/models"
+)
+
+// ErrInvalidCursor is returned when a cursor token cannot be decoded
+var ErrInvalidCursor = errors.New("invalid 'cursor' value")
+
+// EncodeCursor turns a cursor into the opaque token handed to clients
+func EncodeCursor(cursor *models.PageCursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor
+func DecodeCursor(token string) (*models.PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor models.PageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aglili/waakye-directory/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor models.PageCursor
+	}{
+		{
+			name:   "forward",
+			cursor: models.PageCursor{Sort: "rating", Value: "4.5000000000000000", ID: uuid.New()},
+		},
+		{
+			name:   "backward",
+			cursor: models.PageCursor{Sort: "newest", Value: "2024-05-01 12:30:00.123456+00", ID: uuid.New(), Backward: true},
+		},
+		{
+			name:   "text with url unsafe characters",
+			cursor: models.PageCursor{Sort: "name", Value: "Auntie Muni's /waakye?+&", ID: uuid.New()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := EncodeCursor(&tt.cursor)
+			if token == "" {
+				t.Fatal("EncodeCursor returned an empty token")
+			}
+			if _, err := base64.RawURLEncoding.DecodeString(token); err != nil {
+				t.Fatalf("token %q is not unpadded URL-safe base64: %v", token, err)
+			}
+
+			decoded, err := DecodeCursor(token)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if *decoded != tt.cursor {
+				t.Errorf("DecodeCursor = %+v, want %+v", *decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestEncodeNilCursor(t *testing.T) {
+	if token := EncodeCursor(nil); token != "" {
+		t.Errorf("EncodeCursor(nil) = %q, want an empty token", token)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"names"}`))},
+		{"not json", encode("rating:4.5")},
+		{"truncated json", encode(`{"s":"rating","v":"4.5"`)},
+		{"invalid id", encode(`{"s":"rating","v":"4.5","id":"not-a-uuid"}`)},
+		{"wrong value type", encode(`{"s":"rating","v":4.5}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", tt.token, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -64,3 +66,25 @@ func ParseLatitude(c *gin.Context, param string) (float64, bool) {
 func ParseLongitude(c *gin.Context, param string) (float64, bool) {
 	return ParseFloat64WithRange(c, param, -180.0, 180.0)
 }
+
+// ParseOptionalFloat64 parses an optional float64 query parameter within [min, max].
+// It returns nil when the parameter is absent.
+func ParseOptionalFloat64(c *gin.Context, param string, min, max float64) (*float64, error) {
+	valueStr, ok := c.GetQuery(param)
+	if !ok || valueStr == "" {
+		return nil, nil
+	}
+
+	// ParseFloat accepts "NaN" and "Inf", which would slip past the range check below
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("invalid '%s' value: must be a number", param)
+	}
+
+	if value < min || value > max {
+		return nil, fmt.Errorf("'%s' must be between %s and %s", param,
+			strconv.FormatFloat(min, 'f', -1, 64), strconv.FormatFloat(max, 'f', -1, 64))
+	}
+
+	return &value, nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseOptionalInt parses an optional integer query parameter within [min, max].
+// It returns nil when the parameter is absent.
+func ParseOptionalInt(c *gin.Context, param string, min, max int) (*int, error) {
+	valueStr, ok := c.GetQuery(param)
+	if !ok || valueStr == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' value: must be an integer", param)
+	}
+
+	if value < min || value > max {
+		return nil, fmt.Errorf("'%s' must be between %d and %d", param, min, max)
+	}
+
+	return &value, nil
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/aglili/waakye-directory/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
@@ -63,22 +64,58 @@ func RespondWithUnauthorized(ctx *gin.Context, devMessage string, userMessage st
 	})
 }
 
+// RespondWithForbidden sends a 403 Forbidden response with developer and user messages
+func RespondWithForbidden(ctx *gin.Context, devMessage string, userMessage string) {
+	log.Error().Msg(devMessage)
+	ctx.JSON(http.StatusForbidden, gin.H{
+		"error":   userMessage,
+		"details": devMessage,
+	})
+}
+
+// RespondWithUnsupportedMediaType sends a 415 Unsupported Media Type response with developer and user messages
+func RespondWithUnsupportedMediaType(ctx *gin.Context, devMessage string, userMessage string) {
+	log.Error().Msg(devMessage)
+	ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+		"error":   userMessage,
+		"details": devMessage,
+	})
+}
+
+// RespondWithUnprocessableEntity sends a 422 Unprocessable Entity response with developer and user
+// messages and the fields that failed validation
+func RespondWithUnprocessableEntity(ctx *gin.Context, devMessage string, userMessage string, fields []FieldError) {
+	log.Error().Interface("fields", fields).Msg(devMessage)
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":   userMessage,
+		"details": devMessage,
+		"fields":  fields,
+	})
+}
+
 // --- Pagination Helpers ---
 
-// PaginationParams holds the pagination parameters
+// PaginationParams holds the pagination parameters.
+// UseCursor is set when the client asked for keyset pagination by sending a cursor parameter,
+// which may be empty for the first page.
 type PaginationParams struct {
-	Page     int `json:"page"`
-	PageSize int `json:"page_size"`
-	Offset   int `json:"offset"`
+	Page      int                `json:"page"`
+	PageSize  int                `json:"page_size"`
+	Offset    int                `json:"offset"`
+	UseCursor bool               `json:"-"`
+	Cursor    *models.PageCursor `json:"-"`
 }
 
-// PaginatedResponse is a generic struct for paginated data
+// PaginatedResponse is a generic struct for paginated data.
+// NextCursor and PrevCursor are only set in cursor mode, where Page is 0.
 type PaginatedResponse[T any] struct {
 	Data       []T    `json:"data"`
 	Page       int    `json:"page"`
 	PageSize   int    `json:"page_size"`
 	TotalItems int64  `json:"total_items"`
 	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 	Message    string `json:"message"`
 }
 
@@ -115,11 +152,28 @@ func GetPaginationParams(c *gin.Context) (*PaginationParams, error) {
 	// Calculate offset
 	offset := (page - 1) * pageSize
 
-	return &PaginationParams{
+	params := &PaginationParams{
 		Page:     page,
 		PageSize: pageSize,
 		Offset:   offset,
-	}, nil
+	}
+
+	// Keyset pagination is opt-in so existing clients keep using pages. Listings without
+	// cursor support ignore it and keep paging by offset.
+	if cursorToken, ok := c.GetQuery("cursor"); ok {
+		params.UseCursor = true
+
+		if cursorToken != "" {
+			cursor, err := DecodeCursor(cursorToken)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to decode 'cursor'")
+				return nil, err
+			}
+			params.Cursor = cursor
+		}
+	}
+
+	return params, nil
 }
 
 // NewPaginatedResponse creates a new paginated response
@@ -141,3 +195,13 @@ func SendPaginatedResponse[T any](c *gin.Context, data []T, page int, pageSize i
 	response := NewPaginatedResponse(data, page, pageSize, totalItems, message)
 	c.JSON(http.StatusOK, response) // Directly return the PaginatedResponse
 }
+
+// SendCursorPaginatedResponse sends a keyset paginated response with its next and previous cursors
+func SendCursorPaginatedResponse[T any](c *gin.Context, data []T, pageSize int, totalItems int64, keyset *models.KeysetPage, message string) {
+	response := NewPaginatedResponse(data, 0, pageSize, totalItems, message)
+	if keyset != nil {
+		response.NextCursor = EncodeCursor(keyset.Next)
+		response.PrevCursor = EncodeCursor(keyset.Prev)
+	}
+	c.JSON(http.StatusOK, response)
+}
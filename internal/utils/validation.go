@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one invalid field of a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is the 422 body listing every invalid field
+type ValidationErrorResponse struct {
+	Error   string       `json:"error"`
+	Details string       `json:"details"`
+	Fields  []FieldError `json:"fields"`
+}
+
+var registerFieldNames sync.Once
+
+// useJSONFieldNames makes validation errors report fields by their JSON names
+func useJSONFieldNames() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// BindJSON decodes and validates a JSON request body. Malformed JSON gets a 400; fields of the
+// wrong type or failing their binding rules get a 422 listing each field. It returns false once
+// a response has been sent.
+func BindJSON(ctx *gin.Context, obj interface{}, userMessage string) bool {
+	registerFieldNames.Do(useJSONFieldNames)
+
+	err := ctx.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrors):
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fieldError := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fieldError.Namespace()),
+				Rule:    fieldError.Tag(),
+				Message: ruleMessage(fieldError),
+			})
+		}
+		RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, fields)
+	case errors.As(err, &typeError):
+		RespondWithUnprocessableEntity(ctx, err.Error(), userMessage, []FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("must be a %s", jsonTypeName(typeError.Type)),
+		}})
+	case errors.Is(err, io.EOF):
+		RespondWithBadRequest(ctx, "request body is empty", userMessage)
+	default:
+		RespondWithBadRequest(ctx, err.Error(), userMessage)
+	}
+	return false
+}
+
+// fieldPath drops the struct name the validator puts in front of every field
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// ruleMessage turns a failed rule into a sentence a client can show next to the field
+func ruleMessage(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	kind := fieldError.Kind()
+	if kind == reflect.Ptr {
+		kind = fieldError.Type().Elem().Kind()
+	}
+	sized := kind == reflect.String || kind == reflect.Slice || kind == reflect.Map
+
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "gte":
+		return "must be at least " + param
+	case "lte":
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "min":
+		if kind == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", param)
+		}
+		if sized {
+			return fmt.Sprintf("must have at least %s items", param)
+		}
+		return "must be at least " + param
+	case "max":
+		if kind == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", param)
+		}
+		if sized {
+			return fmt.Sprintf("must have at most %s items", param)
+		}
+		return "must be at most " + param
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(param, " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "unique":
+		return "must not contain duplicates"
+	case "uuid":
+		return "must be a valid UUID"
+	}
+	return fmt.Sprintf("failed the '%s' rule", fieldError.Tag())
+}
+
+// jsonTypeName names a Go type the way a JSON client thinks of it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	}
+	return "object"
+}
@@ -0,0 +1,5 @@
+-- Drop index first
+DROP INDEX IF EXISTS idx_waakye_vendors_active;
+
+ALTER TABLE waakye_vendors
+DROP COLUMN deleted_at;
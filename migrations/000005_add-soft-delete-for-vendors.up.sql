@@ -0,0 +1,6 @@
+-- Soft delete support for vendors
+ALTER TABLE waakye_vendors
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Most queries only look at live vendors
+CREATE INDEX idx_waakye_vendors_active ON waakye_vendors(created_at DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- Drop index first
+DROP INDEX IF EXISTS idx_ratings_user_id;
+
+ALTER TABLE vendor_ratings
+DROP COLUMN user_id;
+
+ALTER TABLE waakye_vendors
+DROP COLUMN created_by;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
+DROP TABLE IF EXISTS refresh_tokens;
+
+DROP INDEX IF EXISTS idx_users_email;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,33 @@
+-- Create users table
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email VARCHAR(255) NOT NULL,
+    password_hash TEXT NOT NULL,
+    display_name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Emails are unique regardless of case
+CREATE UNIQUE INDEX idx_users_email ON users(LOWER(email));
+
+-- Refresh tokens are stored hashed and rotated on every use
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+-- Record who created vendors and ratings
+ALTER TABLE waakye_vendors
+ADD COLUMN created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE vendor_ratings
+ADD COLUMN user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_ratings_user_id ON vendor_ratings(user_id);
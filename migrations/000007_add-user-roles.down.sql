@@ -0,0 +1,8 @@
+-- Drop index first
+DROP INDEX IF EXISTS idx_waakye_vendors_owner_id;
+
+ALTER TABLE waakye_vendors
+DROP COLUMN owner_id;
+
+ALTER TABLE users
+DROP COLUMN role;
@@ -0,0 +1,10 @@
+-- Roles drive what a user is allowed to do
+ALTER TABLE users
+ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'contributor'
+    CHECK (role IN ('admin', 'moderator', 'vendor_owner', 'contributor'));
+
+-- The user that manages a vendor listing
+ALTER TABLE waakye_vendors
+ADD COLUMN owner_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_waakye_vendors_owner_id ON waakye_vendors(owner_id);
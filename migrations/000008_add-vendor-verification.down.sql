@@ -0,0 +1,15 @@
+-- Drop indexes first
+DROP INDEX IF EXISTS idx_verification_history_vendor_id;
+DROP INDEX IF EXISTS idx_verification_evidence_vendor_id;
+DROP INDEX IF EXISTS idx_waakye_vendors_verification_status;
+
+DROP TABLE IF EXISTS vendor_verification_history;
+DROP TABLE IF EXISTS vendor_verification_evidence;
+
+ALTER TABLE waakye_vendors
+ADD COLUMN is_verified BOOLEAN DEFAULT false;
+
+UPDATE waakye_vendors SET is_verified = (verification_status = 'verified');
+
+ALTER TABLE waakye_vendors
+DROP COLUMN verification_status;
@@ -0,0 +1,36 @@
+-- Verification is a workflow instead of a flag clients can set
+ALTER TABLE waakye_vendors
+ADD COLUMN verification_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (verification_status IN ('pending', 'under_review', 'verified', 'rejected'));
+
+UPDATE waakye_vendors SET verification_status = 'verified' WHERE is_verified = true;
+
+ALTER TABLE waakye_vendors
+DROP COLUMN is_verified;
+
+CREATE INDEX idx_waakye_vendors_verification_status ON waakye_vendors(verification_status);
+
+-- Photos submitted to back up a verification request
+CREATE TABLE vendor_verification_evidence (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    vendor_id UUID NOT NULL REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    file_url VARCHAR(500) NOT NULL,
+    caption TEXT,
+    submitted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_verification_evidence_vendor_id ON vendor_verification_evidence(vendor_id);
+
+-- Every state change with who made it and why
+CREATE TABLE vendor_verification_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    vendor_id UUID NOT NULL REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_verification_history_vendor_id ON vendor_verification_history(vendor_id, created_at);
@@ -0,0 +1,10 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_locations_city_trgm;
+DROP INDEX IF EXISTS idx_locations_street_address_trgm;
+DROP INDEX IF EXISTS idx_locations_landmark_trgm;
+DROP INDEX IF EXISTS idx_waakye_vendors_name_trgm;
+DROP INDEX IF EXISTS idx_locations_search;
+DROP INDEX IF EXISTS idx_waakye_vendors_search;
+
+-- Remove the extension
+DROP EXTENSION IF EXISTS pg_trgm;
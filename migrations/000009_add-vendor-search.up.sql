@@ -0,0 +1,15 @@
+-- Trigram matching for fuzzy search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Full text search documents, kept as expression indexes so they never go stale
+CREATE INDEX idx_waakye_vendors_search ON waakye_vendors
+USING GIN (to_tsvector('simple', COALESCE(name, '') || ' ' || COALESCE(description, '')));
+
+CREATE INDEX idx_locations_search ON locations
+USING GIN (to_tsvector('simple', COALESCE(landmark, '') || ' ' || street_address || ' ' || city));
+
+-- Trigram indexes for typo tolerant matching on short fields
+CREATE INDEX idx_waakye_vendors_name_trgm ON waakye_vendors USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_locations_landmark_trgm ON locations USING GIN (landmark gin_trgm_ops);
+CREATE INDEX idx_locations_street_address_trgm ON locations USING GIN (street_address gin_trgm_ops);
+CREATE INDEX idx_locations_city_trgm ON locations USING GIN (city gin_trgm_ops);
@@ -0,0 +1,4 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_ratings_vendor_created_at_id;
+DROP INDEX IF EXISTS idx_waakye_vendors_name_id;
+DROP INDEX IF EXISTS idx_waakye_vendors_created_at_id;
@@ -0,0 +1,4 @@
+-- Keyset pagination seeks on (sort key, id), so index the default orders with the id tie breaker
+CREATE INDEX idx_waakye_vendors_created_at_id ON waakye_vendors(created_at DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_waakye_vendors_name_id ON waakye_vendors(name, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_ratings_vendor_created_at_id ON vendor_ratings(vendor_id, created_at DESC, id DESC);
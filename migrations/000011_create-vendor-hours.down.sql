@@ -0,0 +1,7 @@
+-- Drop functions
+DROP FUNCTION IF EXISTS vendor_is_open(UUID, TIMESTAMP WITH TIME ZONE);
+DROP FUNCTION IF EXISTS vendor_openings_on(UUID, DATE);
+
+-- Drop tables
+DROP TABLE IF EXISTS vendor_hour_overrides;
+DROP TABLE IF EXISTS vendor_hours;
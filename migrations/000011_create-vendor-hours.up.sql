@@ -0,0 +1,73 @@
+-- Weekly opening hours, in Africa/Accra wall clock time.
+-- weekday follows EXTRACT(DOW): 0 is Sunday. A span whose closes_at is not after opens_at
+-- runs past midnight, so 18:00-02:00 is overnight and 00:00-00:00 is open all day.
+CREATE TABLE vendor_hours (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    vendor_id UUID NOT NULL REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at TIME NOT NULL,
+    closes_at TIME NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_vendor_hours_vendor_weekday ON vendor_hours(vendor_id, weekday);
+
+-- Holidays and temporary closures. For every date from starts_on to ends_on the weekly hours are
+-- replaced: the vendor is either closed all day or open between opens_at and closes_at.
+CREATE TABLE vendor_hour_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    vendor_id UUID NOT NULL REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT true,
+    opens_at TIME,
+    closes_at TIME,
+    note TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_on >= starts_on),
+    CHECK (is_closed OR (opens_at IS NOT NULL AND closes_at IS NOT NULL))
+);
+
+CREATE INDEX idx_vendor_hour_overrides_vendor ON vendor_hour_overrides(vendor_id, ends_on);
+
+-- The openings that start on a date: the most recent override covering it, else the weekly hours.
+-- Must agree with hours.Schedule in Go, which computes is_open_now and next_open_at.
+CREATE FUNCTION vendor_openings_on(p_vendor_id UUID, p_day DATE)
+RETURNS TABLE (opens_at TIME, closes_at TIME)
+LANGUAGE sql STABLE AS $$
+    WITH override AS (
+        SELECT o.is_closed, o.opens_at, o.closes_at
+        FROM vendor_hour_overrides o
+        WHERE o.vendor_id = p_vendor_id AND p_day BETWEEN o.starts_on AND o.ends_on
+        ORDER BY o.created_at DESC
+        LIMIT 1
+    )
+    SELECT o.opens_at, o.closes_at FROM override o WHERE NOT o.is_closed
+    UNION ALL
+    SELECT h.opens_at, h.closes_at
+    FROM vendor_hours h
+    WHERE h.vendor_id = p_vendor_id
+      AND h.weekday = EXTRACT(DOW FROM p_day)
+      AND NOT EXISTS (SELECT 1 FROM override)
+$$;
+
+-- Whether a vendor is open at an instant, counting overnight spans that started the day before
+CREATE FUNCTION vendor_is_open(p_vendor_id UUID, p_at TIMESTAMP WITH TIME ZONE)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    WITH local AS (
+        SELECT (p_at AT TIME ZONE 'Africa/Accra')::date AS today,
+               (p_at AT TIME ZONE 'Africa/Accra')::time AS now_time
+    )
+    SELECT EXISTS (
+        SELECT 1
+        FROM local, vendor_openings_on(p_vendor_id, local.today) s
+        WHERE local.now_time >= s.opens_at
+          AND (s.closes_at <= s.opens_at OR local.now_time < s.closes_at)
+    ) OR EXISTS (
+        SELECT 1
+        FROM local, vendor_openings_on(p_vendor_id, local.today - 1) s
+        WHERE s.closes_at <= s.opens_at AND local.now_time < s.closes_at
+    )
+$$;
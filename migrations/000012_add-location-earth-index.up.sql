@@ -0,0 +1,3 @@
+-- Index locations as points on the earth cube so nearby searches can use earth_box and
+-- nearest neighbour ordering (<->) instead of computing the distance to every location
+CREATE INDEX idx_locations_earth ON locations USING gist (ll_to_earth(latitude, longitude));
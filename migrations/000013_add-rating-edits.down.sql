@@ -0,0 +1,9 @@
+-- Drop tables
+DROP TABLE IF EXISTS vendor_rating_revisions;
+
+-- Drop indexes
+DROP INDEX IF EXISTS uq_vendor_ratings_vendor_user_active;
+
+-- Remove columns
+ALTER TABLE vendor_ratings
+DROP COLUMN IF EXISTS deleted_at;
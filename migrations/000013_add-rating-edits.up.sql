@@ -0,0 +1,34 @@
+-- Ratings can be withdrawn without losing their history
+ALTER TABLE vendor_ratings
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Keep only the newest rating per user per vendor before enforcing one active rating each
+UPDATE vendor_ratings vr
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE vr.user_id IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM vendor_ratings newer
+      WHERE newer.vendor_id = vr.vendor_id
+        AND newer.user_id = vr.user_id
+        AND (newer.created_at, newer.id) > (vr.created_at, vr.id)
+  );
+
+CREATE UNIQUE INDEX uq_vendor_ratings_vendor_user_active
+ON vendor_ratings(vendor_id, user_id)
+WHERE deleted_at IS NULL AND user_id IS NOT NULL;
+
+-- Every edit or deletion stores the values the rating had before the change
+CREATE TABLE vendor_rating_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rating_id UUID NOT NULL REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('updated', 'deleted')),
+    hygiene_rating INTEGER NOT NULL,
+    value_rating INTEGER NOT NULL,
+    taste_rating INTEGER NOT NULL,
+    service_rating INTEGER NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_vendor_rating_revisions_rating ON vendor_rating_revisions(rating_id, created_at DESC);
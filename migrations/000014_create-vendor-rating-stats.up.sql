@@ -0,0 +1,60 @@
+-- Running totals of every vendor's active ratings, kept up to date as ratings are written
+CREATE TABLE vendor_rating_stats (
+    vendor_id UUID PRIMARY KEY REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    rating_count INTEGER NOT NULL DEFAULT 0,
+    hygiene_sum BIGINT NOT NULL DEFAULT 0,
+    value_sum BIGINT NOT NULL DEFAULT 0,
+    taste_sum BIGINT NOT NULL DEFAULT 0,
+    service_sum BIGINT NOT NULL DEFAULT 0,
+    -- Element n counts the ratings that gave n stars
+    hygiene_histogram INTEGER[] NOT NULL DEFAULT '{0,0,0,0,0}',
+    value_histogram INTEGER[] NOT NULL DEFAULT '{0,0,0,0,0}',
+    taste_histogram INTEGER[] NOT NULL DEFAULT '{0,0,0,0,0}',
+    service_histogram INTEGER[] NOT NULL DEFAULT '{0,0,0,0,0}',
+    last_rated_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO vendor_rating_stats (
+    vendor_id, rating_count, hygiene_sum, value_sum, taste_sum, service_sum,
+    hygiene_histogram, value_histogram, taste_histogram, service_histogram, last_rated_at
+)
+SELECT
+    vendor_id,
+    COUNT(*),
+    SUM(hygiene_rating),
+    SUM(value_rating),
+    SUM(taste_rating),
+    SUM(service_rating),
+    ARRAY[
+        COUNT(*) FILTER (WHERE hygiene_rating = 1),
+        COUNT(*) FILTER (WHERE hygiene_rating = 2),
+        COUNT(*) FILTER (WHERE hygiene_rating = 3),
+        COUNT(*) FILTER (WHERE hygiene_rating = 4),
+        COUNT(*) FILTER (WHERE hygiene_rating = 5)
+    ]::INTEGER[],
+    ARRAY[
+        COUNT(*) FILTER (WHERE value_rating = 1),
+        COUNT(*) FILTER (WHERE value_rating = 2),
+        COUNT(*) FILTER (WHERE value_rating = 3),
+        COUNT(*) FILTER (WHERE value_rating = 4),
+        COUNT(*) FILTER (WHERE value_rating = 5)
+    ]::INTEGER[],
+    ARRAY[
+        COUNT(*) FILTER (WHERE taste_rating = 1),
+        COUNT(*) FILTER (WHERE taste_rating = 2),
+        COUNT(*) FILTER (WHERE taste_rating = 3),
+        COUNT(*) FILTER (WHERE taste_rating = 4),
+        COUNT(*) FILTER (WHERE taste_rating = 5)
+    ]::INTEGER[],
+    ARRAY[
+        COUNT(*) FILTER (WHERE service_rating = 1),
+        COUNT(*) FILTER (WHERE service_rating = 2),
+        COUNT(*) FILTER (WHERE service_rating = 3),
+        COUNT(*) FILTER (WHERE service_rating = 4),
+        COUNT(*) FILTER (WHERE service_rating = 5)
+    ]::INTEGER[],
+    MAX(COALESCE(updated_at, created_at))
+FROM vendor_ratings
+WHERE deleted_at IS NULL
+GROUP BY vendor_id;
@@ -0,0 +1,12 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_review_moderation_log_rating_id;
+DROP INDEX IF EXISTS idx_review_reports_rating_id;
+DROP INDEX IF EXISTS idx_vendor_ratings_moderation_status;
+
+-- Drop tables
+DROP TABLE IF EXISTS review_moderation_log;
+DROP TABLE IF EXISTS review_reports;
+
+-- Remove columns
+ALTER TABLE vendor_ratings
+DROP COLUMN IF EXISTS moderation_status;
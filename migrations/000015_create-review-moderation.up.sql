@@ -0,0 +1,37 @@
+-- Reviews can be flagged for a moderator or hidden from the public
+ALTER TABLE vendor_ratings
+ADD COLUMN moderation_status VARCHAR(20) NOT NULL DEFAULT 'published'
+    CHECK (moderation_status IN ('published', 'flagged', 'hidden'));
+
+CREATE INDEX idx_vendor_ratings_moderation_status ON vendor_ratings(moderation_status)
+WHERE moderation_status <> 'published' AND deleted_at IS NULL;
+
+-- Reports left by users, one per user per review
+CREATE TABLE review_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rating_id UUID NOT NULL REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(20) NOT NULL
+        CHECK (reason IN ('spam', 'abuse', 'hate_speech', 'competitor', 'off_topic', 'other')),
+    details TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (rating_id, reporter_id)
+);
+
+CREATE INDEX idx_review_reports_rating_id ON review_reports(rating_id);
+
+-- Every moderation action with who took it. A NULL actor is the automatic wordlist check.
+CREATE TABLE review_moderation_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rating_id UUID NOT NULL REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL
+        CHECK (action IN ('flagged', 'reported', 'hidden', 'restored', 'deleted')),
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_review_moderation_log_rating_id ON review_moderation_log(rating_id, created_at);
@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP INDEX IF EXISTS idx_notifications_user_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS review_replies;
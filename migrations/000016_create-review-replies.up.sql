@@ -0,0 +1,25 @@
+-- A vendor owner's public reply to a review, one per review
+CREATE TABLE review_replies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    rating_id UUID NOT NULL UNIQUE REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    vendor_id UUID NOT NULL REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- In-app notifications, newest first per user
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(40) NOT NULL,
+    message TEXT NOT NULL,
+    vendor_id UUID REFERENCES waakye_vendors(id) ON DELETE CASCADE,
+    rating_id UUID REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,10 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_vendor_ratings_helpful_count;
+DROP INDEX IF EXISTS idx_review_helpful_votes_user_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS review_helpful_votes;
+
+-- Remove columns
+ALTER TABLE vendor_ratings
+DROP COLUMN IF EXISTS helpful_count;
@@ -0,0 +1,16 @@
+-- One helpful vote per user per review
+CREATE TABLE review_helpful_votes (
+    rating_id UUID NOT NULL REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (rating_id, user_id)
+);
+
+CREATE INDEX idx_review_helpful_votes_user_id ON review_helpful_votes(user_id);
+
+-- Kept in step with review_helpful_votes so reviews can be sorted by it
+ALTER TABLE vendor_ratings
+ADD COLUMN helpful_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_vendor_ratings_helpful_count ON vendor_ratings(vendor_id, helpful_count DESC, id DESC)
+WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_review_photos_upload_id;
+DROP INDEX IF EXISTS idx_uploads_owner_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS review_photos;
+DROP TABLE IF EXISTS uploads;
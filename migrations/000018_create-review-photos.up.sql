@@ -0,0 +1,24 @@
+-- A record of every file uploaded through /api/v1/uploads and who uploaded it
+CREATE TABLE uploads (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    file_name VARCHAR(255) NOT NULL,
+    original_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    file_url VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_uploads_owner_id ON uploads(owner_id);
+
+-- Photos attached to a review, in the order the reviewer listed them
+CREATE TABLE review_photos (
+    rating_id UUID NOT NULL REFERENCES vendor_ratings(id) ON DELETE CASCADE,
+    upload_id UUID NOT NULL REFERENCES uploads(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (rating_id, upload_id)
+);
+
+CREATE INDEX idx_review_photos_upload_id ON review_photos(upload_id);
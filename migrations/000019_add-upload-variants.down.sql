@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_uploads_file_name;
+
+-- Remove columns
+ALTER TABLE uploads DROP COLUMN IF EXISTS variants;
@@ -0,0 +1,5 @@
+-- Resized copies of each uploaded image, keyed by variant name (thumb, small, medium, large)
+ALTER TABLE uploads ADD COLUMN variants JSONB NOT NULL DEFAULT '{}';
+
+-- Vendors point at their image by URL, so its variants are looked up by file name
+CREATE INDEX idx_uploads_file_name ON uploads(file_name);
@@ -0,0 +1,21 @@
+-- Restore indexes
+CREATE INDEX IF NOT EXISTS idx_uploads_file_name ON uploads(file_name);
+
+-- Point linked vendors back at their upload's URL
+UPDATE waakye_vendors wv
+SET image_url = up.file_url
+FROM uploads up
+WHERE up.id = wv.image_upload_id;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_waakye_vendors_image_upload_id;
+DROP INDEX IF EXISTS idx_uploads_created_at;
+
+-- Remove columns
+ALTER TABLE waakye_vendors
+DROP COLUMN IF EXISTS image_upload_id;
+
+ALTER TABLE uploads
+DROP COLUMN IF EXISTS checksum,
+DROP COLUMN IF EXISTS width,
+DROP COLUMN IF EXISTS height;
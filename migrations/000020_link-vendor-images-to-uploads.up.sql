@@ -0,0 +1,23 @@
+-- What was stored for each upload: a SHA-256 of its bytes and, when it could be decoded, its size in pixels
+ALTER TABLE uploads
+ADD COLUMN checksum CHAR(64),
+ADD COLUMN width INTEGER,
+ADD COLUMN height INTEGER;
+
+CREATE INDEX idx_uploads_created_at ON uploads(created_at);
+
+-- Vendors reference their image as an upload rather than a free-form URL
+ALTER TABLE waakye_vendors
+ADD COLUMN image_upload_id UUID REFERENCES uploads(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_waakye_vendors_image_upload_id ON waakye_vendors(image_upload_id);
+
+-- Link the vendors whose image_url already points at an upload. Other URLs are left as they are.
+UPDATE waakye_vendors wv
+SET image_upload_id = up.id, image_url = NULL
+FROM uploads up
+WHERE up.file_name = regexp_replace(wv.image_url, '^.*/', '')
+  AND wv.image_url LIKE '%' || up.file_url;
+
+-- Variants were looked up by file name until vendors referenced uploads
+DROP INDEX IF EXISTS idx_uploads_file_name;
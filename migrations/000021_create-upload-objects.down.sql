@@ -0,0 +1,9 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_uploads_file_name;
+
+-- Remove constraints
+ALTER TABLE uploads
+DROP CONSTRAINT IF EXISTS fk_uploads_object;
+
+-- Drop tables
+DROP TABLE IF EXISTS upload_objects;
@@ -0,0 +1,19 @@
+-- Uploaded files are stored once per distinct content, under a name made from their SHA-256.
+-- Each upload still gets its own row; ref_count is how many of them share the stored file.
+CREATE TABLE upload_objects (
+    file_name VARCHAR(255) PRIMARY KEY,
+    ref_count INTEGER NOT NULL DEFAULT 1 CHECK (ref_count >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every existing upload has a file of its own
+INSERT INTO upload_objects (file_name, ref_count)
+SELECT file_name, COUNT(*)
+FROM uploads
+GROUP BY file_name;
+
+ALTER TABLE uploads
+ADD CONSTRAINT fk_uploads_object FOREIGN KEY (file_name) REFERENCES upload_objects(file_name);
+
+-- A duplicate upload copies the details of an earlier upload of the same file
+CREATE INDEX idx_uploads_file_name ON uploads(file_name);
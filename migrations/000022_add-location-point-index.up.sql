@@ -0,0 +1,3 @@
+-- Index locations as plain longitude/latitude points so map viewports can be found with a
+-- bounding box lookup (<@ box) instead of scanning every location
+CREATE INDEX idx_locations_point ON locations USING gist (point(longitude::float8, latitude::float8));
@@ -0,0 +1,17 @@
+-- Restore columns, pointing at the default local file server since the public URL is not known here
+ALTER TABLE uploads
+ADD COLUMN file_url VARCHAR(500);
+
+UPDATE uploads
+SET file_url = '/uploads/' || file_name;
+
+ALTER TABLE uploads
+ALTER COLUMN file_url SET NOT NULL;
+
+UPDATE uploads
+SET variants = (
+    SELECT COALESCE(jsonb_object_agg(name, variant || jsonb_build_object(
+        'url', '/uploads/' || regexp_replace(file_name, '\.[^./]*$', '') || '_' || name || '.jpg'
+    )), '{}'::jsonb)
+    FROM jsonb_each(variants) AS v(name, variant)
+);
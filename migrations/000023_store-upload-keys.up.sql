@@ -0,0 +1,10 @@
+-- Uploads keep only their storage keys. URLs are built from UPLOAD_PUBLIC_URL when responding, so
+-- moving files behind another host or a CDN needs no data changes.
+UPDATE uploads
+SET variants = (
+    SELECT COALESCE(jsonb_object_agg(name, variant - 'url'), '{}'::jsonb)
+    FROM jsonb_each(variants) AS v(name, variant)
+);
+
+ALTER TABLE uploads
+DROP COLUMN file_url;
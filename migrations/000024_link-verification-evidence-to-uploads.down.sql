@@ -0,0 +1,16 @@
+-- Point linked evidence back at its upload, on the default local file server since the public URL is not known here
+UPDATE vendor_verification_evidence e
+SET file_url = '/uploads/' || up.file_name
+FROM uploads up
+WHERE up.id = e.upload_id;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_verification_evidence_upload_id;
+
+-- Remove columns
+ALTER TABLE vendor_verification_evidence
+DROP CONSTRAINT IF EXISTS vendor_verification_evidence_file_check,
+DROP COLUMN IF EXISTS upload_id;
+
+ALTER TABLE vendor_verification_evidence
+ALTER COLUMN file_url SET NOT NULL;
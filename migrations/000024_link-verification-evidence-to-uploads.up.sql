@@ -0,0 +1,26 @@
+-- Evidence references the upload it shows, so the upload janitor keeps the file
+ALTER TABLE vendor_verification_evidence
+ADD COLUMN upload_id UUID REFERENCES uploads(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_verification_evidence_upload_id ON vendor_verification_evidence(upload_id);
+
+-- Link the evidence whose file_url points at an upload, preferring the submitter's own upload of the file.
+-- Other URLs are left as they are.
+UPDATE vendor_verification_evidence e
+SET upload_id = (
+    SELECT up.id
+    FROM uploads up
+    WHERE up.file_name = regexp_replace(e.file_url, '^.*/', '')
+    ORDER BY up.owner_id IS NOT DISTINCT FROM e.submitted_by DESC, up.created_at
+    LIMIT 1
+);
+
+ALTER TABLE vendor_verification_evidence
+ALTER COLUMN file_url DROP NOT NULL;
+
+UPDATE vendor_verification_evidence
+SET file_url = NULL
+WHERE upload_id IS NOT NULL;
+
+ALTER TABLE vendor_verification_evidence
+ADD CONSTRAINT vendor_verification_evidence_file_check CHECK (upload_id IS NOT NULL OR file_url IS NOT NULL);